@@ -0,0 +1,79 @@
+// brains.go: The brains command, which runs the simulation headless and exports the brains of the most common genomes.
+
+package main
+
+import (
+	"biogo/v2/simulation"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func runBrains(args []string) error {
+	fs := flag.NewFlagSet("brains", flag.ContinueOnError)
+	generations := fs.Int("generations", 100, "generations to run before exporting")
+	top := fs.Int("top", 5, "number of genomes to export")
+	out := fs.String("out", "brains", "directory to write the exported brains to")
+	format := fs.String("format", "both", "export format: dot, json or both")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	if *format != "dot" && *format != "json" && *format != "both" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+
+	sim, err := newSimulation()
+	if err != nil {
+		return err
+	}
+	for sim.Generation < *generations {
+		sim.RunGeneration()
+	}
+
+	for _, pop := range sim.Populations {
+		// Brains are named by population when there's more than one
+		prefix := "brain"
+		if len(sim.Populations) > 1 {
+			prefix = pop.Name + "-brain"
+		}
+		for i, gc := range pop.MostCommonGenomes(*top) {
+			rank := i + 1
+			base := filepath.Join(*out, fmt.Sprintf("%s-%d", prefix, rank))
+			title := fmt.Sprintf("Generation %d, rank %d, %d creatures", sim.Generation, rank, gc.Count)
+			nnet, err := writeBrain(base, title, gc.Genome, *format)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%d: %d creatures, %d edges -> %s\n", rank, gc.Count, len(nnet.Edges), base)
+		}
+	}
+	return nil
+}
+
+// writeBrain writes a genome and its net, in the given format, to files named base plus an extension.
+func writeBrain(base, title string, genome *simulation.Genome, format string) (*simulation.NeuralNet, error) {
+	nnet := simulation.CreateNeuralNetworkFromGenome(genome.Brain, genome.NeuronCount)
+	if err := os.WriteFile(base+".genome", []byte(genome.BinaryString()+"\n"), 0644); err != nil {
+		return nil, err
+	}
+	if format != "json" {
+		if err := os.WriteFile(base+".dot", []byte(nnet.DOT(title)), 0644); err != nil {
+			return nil, err
+		}
+	}
+	if format != "dot" {
+		data, err := json.MarshalIndent(nnet.Graph(), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(base+".json", data, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return nnet, nil
+}
@@ -0,0 +1,97 @@
+// commands.go: Headless subcommands that run instead of the UI when biogo is given arguments.
+
+package main
+
+import (
+	"biogo/v2/simulation"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"diff":    {"diff <genome|file> <genome|file>\tshow trait, gene and network differences between two genomes", runDiff},
+	"inspect": {"inspect <genome|file>\t\tdecode a genome's traits, genes and pruned network", runInspect},
+	"brains":  {"brains [-generations n] [-top n] [-out dir] [-format dot|json|both]\trun headless and export the brains of the most common genomes", runBrains},
+	"run":     {"run [-generations n] [-seed n] [-set Name=value]...\trun headless with parameter overrides", runRun},
+	"record":  {"record [-generations n] [-every n] [-at 1,51,...] [-ticks n] [-format gif|png] [-scale n] [-color mode] [-delay n] [-seed n] [-set Name=value]... [-out dir]\trun headless and record generations as GIFs or PNGs", runRecord},
+	"sweep":   {"sweep [-generations n] [-seeds 1,2,...] [-set Name=v1,v2,...]... [-list file] [-parallel n] [-target rate] [-out dir]\trun a parameter sweep and summarise it", runSweep},
+	"islands": {"islands [-islands n] [-generations n] [-interval n] [-migrants n] [-topology ring|full|random] [-out dir]\trun islands with migration between them", runIslands},
+}
+
+// errUsage is returned by a command given the wrong arguments.
+var errUsage = errors.New("wrong arguments")
+
+func runCommand(name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", name, usage())
+	}
+	err := cmd.run(args)
+	if errors.Is(err, errUsage) {
+		return fmt.Errorf("usage: biogo %s", cmd.usage)
+	}
+	return err
+}
+
+func usage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	str := "Usage: biogo [-profile] [-stats file] [command]\nCommands:\n"
+	for _, name := range names {
+		str += "  " + commands[name].usage + "\n"
+	}
+	return str
+}
+
+// readGenomeArg parses a genome given either directly on the command line or as the path to a file holding one.
+func readGenomeArg(arg string) (*simulation.Genome, error) {
+	if data, err := os.ReadFile(arg); err == nil {
+		arg = string(data)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	g, err := simulation.ParseGenome(strings.TrimSpace(arg))
+	if err != nil {
+		return nil, fmt.Errorf("reading genome: %w", err)
+	}
+	return g, nil
+}
+
+func runDiff(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	a, err := readGenomeArg(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := readGenomeArg(args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Print(simulation.DiffGenomes(a, b))
+	return nil
+}
+
+func runInspect(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	g, err := readGenomeArg(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(g.Inspect())
+	return nil
+}
@@ -0,0 +1,60 @@
+// islands.go: The islands command, which runs several simulations at once with migration between them.
+
+package main
+
+import (
+	"biogo/v2/simulation"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func runIslands(args []string) error {
+	fs := flag.NewFlagSet("islands", flag.ContinueOnError)
+	count := fs.Int("islands", 4, "number of islands")
+	generations := fs.Int("generations", 100, "generations to run")
+	interval := fs.Int("interval", 10, "generations between migrations, 0 for none")
+	migrants := fs.Int("migrants", 5, "genomes each island sends to each destination per migration")
+	topologyName := fs.String("topology", "ring", "migration topology: ring, full or random")
+	out := fs.String("out", "islands", "directory to write each island's stats to")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 || *count < 1 {
+		return errUsage
+	}
+	topology, err := simulation.ParseMigrationTopology(*topologyName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+
+	a, err := simulation.NewArchipelago(*count, topology, *interval, *migrants)
+	if err != nil {
+		return err
+	}
+	for _, island := range a.Islands {
+		f, err := os.Create(filepath.Join(*out, island.Name+".csv"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := island.Stats.WriteCSV(f); err != nil {
+			return err
+		}
+	}
+	for a.Generation() < *generations {
+		if err := a.RunGeneration(); err != nil {
+			return err
+		}
+	}
+
+	// The last generation's rows, one per population
+	for _, island := range a.Islands {
+		history := island.Stats.History
+		for _, st := range history[len(history)-len(island.Populations):] {
+			fmt.Printf("%s %s: %.2f%% survived generation %d\n", island.Name, st.Population, st.SurvivalRate*100, st.Generation)
+		}
+	}
+	return nil
+}
@@ -1,3 +1,7 @@
+// Since Go 1.24 rand.Seed does nothing unless randseednop=0, and runs need to be
+// reproducible from their -seed.
+//go:debug randseednop=0
+
 package main
 
 import (
@@ -5,6 +9,7 @@ import (
 	"biogo/v2/ui"
 	"flag"
 	"log"
+	"math"
 	"math/rand"
 	"os"
 	"runtime"
@@ -14,6 +19,22 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
+var statsPath string
+
+// newSimulation creates a simulation, recording stats to the -stats file if one was given.
+// The file stays open for the rest of the run; each row is flushed as it's written.
+func newSimulation() (*simulation.Simulation, error) {
+	sim := simulation.New()
+	if statsPath == "" {
+		return sim, nil
+	}
+	f, err := os.Create(statsPath)
+	if err != nil {
+		return nil, err
+	}
+	return sim, sim.Stats.WriteCSV(f)
+}
+
 func main() {
 	enableProfile := false
 	// Check environment variable
@@ -22,6 +43,7 @@ func main() {
 	}
 	// Check command-line flag
 	profileFlag := flag.Bool("profile", false, "Enable CPU and memory profiling")
+	flag.StringVar(&statsPath, "stats", "", "Write per generation stats as CSV to this file")
 	flag.Parse()
 	if *profileFlag {
 		enableProfile = true
@@ -54,7 +76,18 @@ func main() {
 		}()
 	}
 
-	sim := simulation.New()
+	// Subcommands run headless instead of opening the UI
+	if flag.NArg() > 0 {
+		if err := runCommand(flag.Arg(0), flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	sim, err := newSimulation()
+	if err != nil {
+		log.Fatal(err)
+	}
 	// for i := 0; i < 2*simulation.Params.MaxAge; i++ {
 	// 	start := time.Now()
 	// 	sim.Update()
@@ -66,7 +99,15 @@ func main() {
 
 	game := ui.NewGame(sim)
 
-	ebiten.SetWindowSize(simulation.Params.GridWidth*2, simulation.Params.GridHeight*2)
+	// Open at BlockSize pixels a cell, shrunk to fit the screen. The view fits itself to the window
+	// as it's resized, and can be zoomed and panned from there.
+	width, height := float64(sim.Grid.SizeX()*ui.BlockSize), float64(sim.Grid.SizeY()*ui.BlockSize)
+	if sw, sh := ebiten.ScreenSizeInFullscreen(); sw > 0 && sh > 0 {
+		scale := math.Min(1, math.Min(0.9*float64(sw)/width, 0.9*float64(sh)/height))
+		width, height = width*scale, height*scale
+	}
+	ebiten.SetWindowSize(int(width), int(height))
+	ebiten.SetWindowResizable(true)
 	ebiten.SetWindowTitle("Genetic Simulation")
 
 	if err := ebiten.RunGame(game); err != nil {
@@ -0,0 +1,85 @@
+// record.go: The record command, which runs the simulation headless and records chosen generations as GIFs or PNGs.
+
+package main
+
+import (
+	"biogo/v2/render"
+	"biogo/v2/simulation"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+func parseGenerations(s string) ([]int, error) {
+	generations := []int{}
+	for _, field := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid generation %q, generations are numbered from 1", field)
+		}
+		generations = append(generations, n)
+	}
+	return generations, nil
+}
+
+func runRecord(args []string) (err error) {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	generations := fs.Int("generations", 100, "generations to run")
+	every := fs.Int("every", 50, "record every nth generation, starting with the first")
+	at := fs.String("at", "", "record just these generations, as 1,51,...")
+	ticks := fs.Int("ticks", 5, "ticks between frames")
+	format := fs.String("format", render.GIF, "output format: gif or png")
+	scale := fs.Int("scale", 1, "pixels per grid cell")
+	colors := fs.String("color", render.ColorGenome.String(), "colour mode: genome, species, lineage, mutation-rate, sight-distance, responsiveness, energy, age, brain-size or action")
+	delay := fs.Int("delay", 4, "delay between GIF frames in hundredths of a second")
+	seed := fs.Int64("seed", 0, "random seed, 0 for a time based one")
+	out := fs.String("out", "frames", "directory to write the recordings to")
+	var sets setFlags
+	fs.Var(&sets, "set", "override a parameter as Name=value, can be repeated")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	rec, err := render.NewRecorder(*out, *format)
+	if err != nil {
+		return err
+	}
+	rec.Every, rec.TickStep, rec.Scale, rec.Delay = *every, *ticks, *scale, *delay
+	if rec.Mode, err = render.ParseColorMode(*colors); err != nil {
+		return err
+	}
+	if *at != "" {
+		if rec.Generations, err = parseGenerations(*at); err != nil {
+			return err
+		}
+	}
+	if err := applySets(sets); err != nil {
+		return err
+	}
+	if *seed != 0 {
+		rand.Seed(*seed)
+	}
+
+	var sim *simulation.Simulation
+	// The simulation panics when a population goes extinct
+	defer func() {
+		if r := recover(); r != nil {
+			generation := 0
+			if sim != nil {
+				generation = sim.Generation
+			}
+			err = fmt.Errorf("generation %d: %v", generation, r)
+		}
+	}()
+	sim, err = newSimulation()
+	if err != nil {
+		return err
+	}
+	for sim.Generation < *generations {
+		if err := rec.RunGeneration(sim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
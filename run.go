@@ -0,0 +1,74 @@
+// run.go: The run command, which runs the simulation headless with parameters and a seed from the command line.
+
+package main
+
+import (
+	"biogo/v2/simulation"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// setFlags collects repeated -set flags.
+type setFlags []string
+
+func (s *setFlags) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *setFlags) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// applySets applies Name=value overrides to simulation.Params.
+func applySets(sets []string) error {
+	for _, set := range sets {
+		name, value, ok := strings.Cut(set, "=")
+		if !ok {
+			return fmt.Errorf("-set %q should be Name=value", set)
+		}
+		if err := simulation.Params.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runRun(args []string) (err error) {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	generations := fs.Int("generations", 100, "generations to run")
+	seed := fs.Int64("seed", 0, "random seed, 0 for a time based one")
+	var sets setFlags
+	fs.Var(&sets, "set", "override a parameter as Name=value, can be repeated")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	if err := applySets(sets); err != nil {
+		return err
+	}
+	if *seed != 0 {
+		rand.Seed(*seed)
+	}
+
+	var sim *simulation.Simulation
+	// The simulation panics when a population goes extinct
+	defer func() {
+		if r := recover(); r != nil {
+			generation := 0
+			if sim != nil {
+				generation = sim.Generation
+			}
+			err = fmt.Errorf("generation %d: %v", generation, r)
+		}
+	}()
+	sim, err = newSimulation()
+	if err != nil {
+		return err
+	}
+	for sim.Generation < *generations {
+		sim.RunGeneration()
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// sweep.go: The sweep command, which runs the simulation over a grid or list of parameter overrides and seeds, and summarises the results.
+
+package main
+
+import (
+	"biogo/v2/simulation"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// sweepRun is one run of a sweep: a set of overrides with a seed.
+type sweepRun struct {
+	ID   string
+	Sets []string // Name=value overrides
+	Seed int64
+	Dir  string
+	Err  error
+}
+
+// sweepSets builds the parameter sets to run. Each entry of the list (or a single empty set without
+// one) is combined with every combination of the -set values.
+func sweepSets(listPath string, grid []string) ([][]string, error) {
+	sets := [][]string{{}}
+	if listPath != "" {
+		data, err := os.ReadFile(listPath)
+		if err != nil {
+			return nil, err
+		}
+		var list []map[string]interface{}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", listPath, err)
+		}
+		sets = [][]string{}
+		for _, entry := range list {
+			set := []string{}
+			for name, value := range entry {
+				set = append(set, name+"="+sweepValue(value))
+			}
+			sort.Strings(set)
+			sets = append(sets, set)
+		}
+	}
+	for _, g := range grid {
+		name, values, ok := strings.Cut(g, "=")
+		if !ok {
+			return nil, fmt.Errorf("-set %q should be Name=value1,value2,...", g)
+		}
+		expanded := [][]string{}
+		for _, set := range sets {
+			for _, value := range strings.Split(values, ",") {
+				combined := append(append([]string{}, set...), name+"="+value)
+				expanded = append(expanded, combined)
+			}
+		}
+		sets = expanded
+	}
+	return sets, nil
+}
+
+// sweepValue formats a JSON value as Parameters.Set reads it. Numbers are written out in full, as
+// %v would give 1e+06 for a million, which integer parameters reject.
+func sweepValue(value interface{}) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func parseSeeds(s string) ([]int64, error) {
+	seeds := []int64{}
+	for _, field := range strings.Split(s, ",") {
+		seed, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil || seed == 0 {
+			return nil, fmt.Errorf("invalid seed %q, seeds must be non-zero integers", field)
+		}
+		seeds = append(seeds, seed)
+	}
+	return seeds, nil
+}
+
+func runSweep(args []string) error {
+	fs := flag.NewFlagSet("sweep", flag.ContinueOnError)
+	generations := fs.Int("generations", 100, "generations for each run")
+	seedList := fs.String("seeds", "1", "comma separated seeds, every parameter set runs once per seed")
+	parallel := fs.Int("parallel", runtime.NumCPU(), "runs to have going at once")
+	out := fs.String("out", "sweep", "directory to write the results to")
+	target := fs.Float64("target", 0.9, "survival rate the summary reports the generations taken to reach")
+	listPath := fs.String("list", "", "JSON file holding a list of parameter sets, each an object of Name: value")
+	var grid setFlags
+	fs.Var(&grid, "set", "sweep a parameter over values as Name=value1,value2,..., can be repeated to sweep a grid")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 || *parallel < 1 {
+		return errUsage
+	}
+	sets, err := sweepSets(*listPath, grid)
+	if err != nil {
+		return err
+	}
+	seeds, err := parseSeeds(*seedList)
+	if err != nil {
+		return err
+	}
+	// Catch bad parameter names before starting anything
+	for _, set := range sets {
+		saved := *simulation.Params
+		err := applySets(set)
+		*simulation.Params = saved
+		if err != nil {
+			return err
+		}
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	runs := []*sweepRun{}
+	for _, set := range sets {
+		for _, seed := range seeds {
+			id := fmt.Sprintf("run-%03d", len(runs)+1)
+			runs = append(runs, &sweepRun{ID: id, Sets: set, Seed: seed, Dir: filepath.Join(*out, "runs", id)})
+		}
+	}
+	fmt.Printf("Sweeping %d parameter sets x %d seeds = %d runs, %d at a time\n", len(sets), len(seeds), len(runs), *parallel)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, *parallel)
+	for _, run := range runs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(run *sweepRun) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			run.Err = run.start(exe, *generations)
+			status := "done"
+			if run.Err != nil {
+				status = "failed: " + run.Err.Error()
+			}
+			fmt.Printf("%s %s seed=%d %s\n", run.ID, strings.Join(run.Sets, " "), run.Seed, status)
+		}(run)
+	}
+	wg.Wait()
+
+	return writeSweepSummary(*out, runs, float32(*target))
+}
+
+// start runs the simulation in a child process, as Params is global and each run needs its own.
+func (r *sweepRun) start(exe string, generations int) error {
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return err
+	}
+	params := strings.Join(append(append([]string{}, r.Sets...), fmt.Sprintf("seed=%d", r.Seed)), "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(r.Dir, "params.txt"), []byte(params), 0644); err != nil {
+		return err
+	}
+	log, err := os.Create(filepath.Join(r.Dir, "log.txt"))
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	args := []string{"-stats", filepath.Join(r.Dir, "stats.csv"), "run", "-generations", strconv.Itoa(generations), "-seed", strconv.FormatInt(r.Seed, 10)}
+	for _, set := range r.Sets {
+		args = append(args, "-set", set)
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v, see %s", err, filepath.Join(r.Dir, "log.txt"))
+	}
+	return nil
+}
+
+// writeSweepSummary reads back each run's stats and writes summary.csv, and prints it as a table.
+func writeSweepSummary(out string, runs []*sweepRun, target float32) error {
+	targetColumn := fmt.Sprintf("generations_to_%g%%", target*100)
+	header := []string{"run", "parameters", "seed", "status", "generations", "final_survival", "best_survival", targetColumn}
+	rows := [][]string{header}
+	for _, run := range runs {
+		status := "ok"
+		if run.Err != nil {
+			status = "failed"
+		}
+		row := []string{run.ID, strings.Join(run.Sets, " "), strconv.FormatInt(run.Seed, 10), status, "0", "", "", ""}
+		if f, err := os.Open(filepath.Join(run.Dir, "stats.csv")); err == nil {
+			history, err := simulation.ReadStatsCSV(f)
+			f.Close()
+			if combined := simulation.CombinePopulations(history); err == nil && len(combined) > 0 {
+				best := float32(0)
+				for _, st := range combined {
+					if st.SurvivalRate > best {
+						best = st.SurvivalRate
+					}
+				}
+				last := combined[len(combined)-1]
+				row[4] = strconv.Itoa(last.Generation)
+				row[5] = fmt.Sprintf("%.4f", last.SurvivalRate)
+				row[6] = fmt.Sprintf("%.4f", best)
+				if g := simulation.GenerationsToSurvival(history, target); g > 0 {
+					row[7] = strconv.Itoa(g)
+				} else {
+					row[7] = "-"
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	f, err := os.Create(filepath.Join(out, "summary.csv"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("Results written to %s\n", out)
+	return nil
+}
@@ -0,0 +1,152 @@
+// distance.go: Distance fields over the grid and the flow fields they give, found by breadth first search and cached until the walls change.
+
+package grid
+
+// Unreachable is the distance of a cell no source can reach.
+const Unreachable = -1
+
+// DistanceField holds the number of moves from each cell to the nearest source cell. Diagonal
+// moves count as one, as they do for creatures.
+type DistanceField struct {
+	width, height int
+	dist          []int32
+	blocked       bool // Whether impassable cells stop the search
+}
+
+// NewDistanceField searches out from every source at once, only stepping onto cells passable
+// allows (every cell if it's nil).
+func NewDistanceField(width, height int, sources []Coord, passable func(Coord) bool) *DistanceField {
+	f := &DistanceField{width: width, height: height, dist: make([]int32, width*height), blocked: passable != nil}
+	for i := range f.dist {
+		f.dist[i] = Unreachable
+	}
+	queue := make([]Coord, 0, len(sources))
+	for _, s := range sources {
+		if f.inBounds(s) && f.dist[f.index(s)] == Unreachable {
+			f.dist[f.index(s)] = 0
+			queue = append(queue, s)
+		}
+	}
+	for head := 0; head < len(queue); head++ {
+		loc := queue[head]
+		d := f.dist[f.index(loc)] + 1
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				next := Coord{X: loc.X + dx, Y: loc.Y + dy}
+				if !f.inBounds(next) || f.dist[f.index(next)] != Unreachable || (passable != nil && !passable(next)) {
+					continue
+				}
+				f.dist[f.index(next)] = d
+				queue = append(queue, next)
+			}
+		}
+	}
+	return f
+}
+
+func (f *DistanceField) inBounds(loc Coord) bool {
+	return 0 <= loc.X && loc.X < f.width && 0 <= loc.Y && loc.Y < f.height
+}
+
+func (f *DistanceField) index(loc Coord) int {
+	return loc.X*f.height + loc.Y
+}
+
+// At returns the distance from loc to the nearest source, or Unreachable.
+func (f *DistanceField) At(loc Coord) int {
+	if !f.inBounds(loc) {
+		return Unreachable
+	}
+	return int(f.dist[f.index(loc)])
+}
+
+// Gradient returns how much nearer a step in dir takes loc to the sources: 1 nearer, -1 further, 0
+// for no change or when either cell is unreachable.
+func (f *DistanceField) Gradient(loc Coord, dir Dir) float32 {
+	here, there := f.At(loc), f.At(Coord{X: loc.X + dir.X, Y: loc.Y + dir.Y})
+	if here == Unreachable || there == Unreachable {
+		return 0
+	}
+	return float32(max(-1, min(1, here-there)))
+}
+
+// Downhill returns the flow field's direction at loc: the step that gets nearest the sources, or
+// CENTER at a source or an unreachable cell.
+func (f *DistanceField) Downhill(loc Coord) Dir {
+	best, bestDir := f.At(loc), CENTER
+	if best == Unreachable {
+		return CENTER
+	}
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			if d := f.At(Coord{X: loc.X + dx, Y: loc.Y + dy}); d != Unreachable && d < best {
+				best, bestDir = d, Dir{X: dx, Y: dy}
+			}
+		}
+	}
+	return bestDir
+}
+
+// dependsOn reports whether a wall going up or coming down at loc could change the field. A field
+// walls block only changes if the search reached loc or one of its neighbours.
+func (f *DistanceField) dependsOn(loc Coord) bool {
+	if !f.blocked {
+		return true
+	}
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			if f.At(Coord{X: loc.X + dx, Y: loc.Y + dy}) != Unreachable {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// invalidateFields forgets the cached fields a wall changing at loc could affect, to be rebuilt
+// when next asked for.
+func (g *Grid) invalidateFields(loc Coord) {
+	for name, f := range g.fields {
+		if f.dependsOn(loc) {
+			delete(g.fields, name)
+		}
+	}
+}
+
+// cachedField returns the named field, building it the first time it's asked for since a wall it
+// depends on changed.
+func (g *Grid) cachedField(name string, build func() *DistanceField) *DistanceField {
+	if f, ok := g.fields[name]; ok {
+		return f
+	}
+	if g.fields == nil {
+		g.fields = map[string]*DistanceField{}
+	}
+	f := build()
+	g.fields[name] = f
+	return f
+}
+
+// BarrierDistance returns each cell's distance to the nearest wall or barrier.
+func (g *Grid) BarrierDistance() *DistanceField {
+	return g.cachedField("barriers", func() *DistanceField {
+		sources := []Coord{}
+		for x := range g.Data {
+			for y, v := range g.Data[x] {
+				if v == WALL {
+					sources = append(sources, Coord{X: x, Y: y})
+				}
+			}
+		}
+		return NewDistanceField(g.SizeX(), g.SizeY(), sources, nil)
+	})
+}
+
+// PathDistance returns each cell's distance to the nearest of the named set of cells, going around
+// walls and barriers. sources lists the cells, and is only called when the field needs building;
+// the field is cached under name until a wall changes.
+func (g *Grid) PathDistance(name string, sources func() []Coord) *DistanceField {
+	return g.cachedField("path "+name, func() *DistanceField {
+		return NewDistanceField(g.SizeX(), g.SizeY(), sources(), func(loc Coord) bool { return !g.IsBarrierAt(loc) })
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -18,12 +20,40 @@ type MapType int
 
 const (
 	MIDDLE_WALL MapType = iota
+	NO_WALLS
 )
 
+var mapTypeNames = []string{"MIDDLE_WALL", "NO_WALLS"}
+
+func (t MapType) String() string {
+	if int(t) >= 0 && int(t) < len(mapTypeNames) {
+		return mapTypeNames[t]
+	}
+	return fmt.Sprintf("MapType(%d)", int(t))
+}
+
+// ParseMapType reads a map type by name (case insensitive) or number.
+func ParseMapType(s string) (MapType, error) {
+	for i, name := range mapTypeNames {
+		if strings.EqualFold(s, name) {
+			return MapType(i), nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(mapTypeNames) {
+		return MapType(n), nil
+	}
+	return 0, fmt.Errorf("unknown map type %q", s)
+}
+
 type Grid struct {
 	Data          [][]int
 	WallLocations []Coord
 	Type          MapType
+	Walls         []Box      // Static walls added to the map type's
+	Barriers      []*Barrier // Walls that change during a generation
+	Layers        []*Layer
+
+	fields map[string]*DistanceField // Cached distance fields, dropped when a wall they depend on changes
 }
 
 func NewGrid(xSize, ySize int, gridMap int) *Grid {
@@ -55,6 +85,26 @@ func (grid *Grid) ZeroFill() {
 		}
 	}
 	grid.WallLocations = []Coord{}
+	grid.fields = nil
+	for _, b := range grid.Barriers {
+		b.cells = nil
+	}
+	for _, l := range grid.Layers {
+		l.Clear()
+	}
+}
+
+// AddLayer adds a scalar field layer the size of the grid and returns its index in Layers.
+func (grid *Grid) AddLayer(name string, diffusion, decay float32) int {
+	grid.Layers = append(grid.Layers, NewLayer(name, grid.SizeX(), grid.SizeY(), diffusion, decay))
+	return len(grid.Layers) - 1
+}
+
+// UpdateLayers steps every layer, with walls blocking their diffusion.
+func (grid *Grid) UpdateLayers() {
+	for _, l := range grid.Layers {
+		l.Update(func(loc Coord) bool { return !grid.IsBarrierAt(loc) })
+	}
 }
 
 func (g *Grid) CreateWall() {
@@ -69,12 +119,19 @@ func (g *Grid) CreateWall() {
 		maxY := minY + g.SizeY()/2
 		g.DrawBox(minX, minY, maxX, maxY)
 	}
+	for _, box := range g.Walls {
+		g.DrawBox(box.MinX, box.MinY, box.MaxX, box.MaxY)
+	}
+	g.UpdateBarriers(0)
 }
 
 func (g *Grid) DrawBox(minX, minY, maxX, maxY int) {
 	for x := minX; x < maxX; x++ {
 		for y := minY; y < maxY; y++ {
 			coord := Coord{X: x, Y: y}
+			if !g.IsInBounds(coord) {
+				continue
+			}
 			g.Set(coord, WALL)
 			g.WallLocations = append(g.WallLocations, coord)
 		}
@@ -107,7 +164,11 @@ func (grid Grid) IsOccupiedAt(loc Coord) bool {
 	return grid.Data[loc.X][loc.Y] != EMPTY && grid.Data[loc.X][loc.Y] != WALL
 }
 
-// IsBarrierAt()
+// IsBarrierAt reports whether loc is a wall, static or a barrier in place.
+func (grid Grid) IsBarrierAt(loc Coord) bool {
+	return grid.Data[loc.X][loc.Y] == WALL
+}
+
 func (grid Grid) IsBorder(loc Coord) bool {
 	return loc.X == 0 || loc.X == grid.SizeX()-1 || loc.Y == 0 || loc.Y == grid.SizeY()-1
 }
@@ -117,6 +178,9 @@ func (grid Grid) At(loc Coord) int {
 }
 
 func (grid *Grid) Set(loc Coord, id int) {
+	if (id == WALL) != (grid.Data[loc.X][loc.Y] == WALL) {
+		grid.invalidateFields(loc)
+	}
 	grid.Data[loc.X][loc.Y] = id
 }
 
@@ -0,0 +1,143 @@
+// layer.go: Scalar field layers over the grid (e.g. pheromones) that diffuse and decay every step.
+
+package grid
+
+// Cells below this are treated as empty, so a layer goes quiet once its scent has faded.
+const layerEpsilon = 1e-4
+
+// Layer is a scalar field the size of the grid, with values in 0...1. Only the bounding box of
+// non-empty cells (plus the one cell border scent can spread into) is updated, so an unused
+// layer costs nothing.
+type Layer struct {
+	Name      string
+	Data      [][]float32
+	Diffusion float32 // Fraction of each cell that spreads to its neighbours every step
+	Decay     float32 // Fraction of each cell lost every step
+
+	scratch                [][]float32
+	minX, minY, maxX, maxY int
+	active                 bool
+}
+
+func NewLayer(name string, xSize, ySize int, diffusion, decay float32) *Layer {
+	l := &Layer{Name: name, Diffusion: diffusion, Decay: decay}
+	l.Data = make([][]float32, xSize)
+	l.scratch = make([][]float32, xSize)
+	for x := range l.Data {
+		l.Data[x] = make([]float32, ySize)
+		l.scratch[x] = make([]float32, ySize)
+	}
+	return l
+}
+
+func (l *Layer) SizeX() int {
+	return len(l.Data)
+}
+
+func (l *Layer) SizeY() int {
+	return len(l.Data[0])
+}
+
+func (l *Layer) Active() bool {
+	return l.active
+}
+
+func (l *Layer) At(loc Coord) float32 {
+	if loc.X < 0 || loc.X >= l.SizeX() || loc.Y < 0 || loc.Y >= l.SizeY() {
+		return 0
+	}
+	return l.Data[loc.X][loc.Y]
+}
+
+// Add deposits amount at loc, capping the cell at 1.
+func (l *Layer) Add(loc Coord, amount float32) {
+	if loc.X < 0 || loc.X >= l.SizeX() || loc.Y < 0 || loc.Y >= l.SizeY() || amount <= 0 {
+		return
+	}
+	v := l.Data[loc.X][loc.Y] + amount
+	if v > 1 {
+		v = 1
+	}
+	l.Data[loc.X][loc.Y] = v
+	if !l.active {
+		l.minX, l.maxX, l.minY, l.maxY = loc.X, loc.X, loc.Y, loc.Y
+		l.active = true
+		return
+	}
+	if loc.X < l.minX {
+		l.minX = loc.X
+	}
+	if loc.X > l.maxX {
+		l.maxX = loc.X
+	}
+	if loc.Y < l.minY {
+		l.minY = loc.Y
+	}
+	if loc.Y > l.maxY {
+		l.maxY = loc.Y
+	}
+}
+
+// Gradient returns how much the layer increases from loc towards loc + d.
+// It's in -1...1 as cells are in 0...1.
+func (l *Layer) Gradient(loc Coord, d Dir) float32 {
+	return l.At(Coord{X: loc.X + d.X, Y: loc.Y + d.Y}) - l.At(Coord{X: loc.X - d.X, Y: loc.Y - d.Y})
+}
+
+// Update diffuses and decays the layer by one step. Each cell keeps 1 - Diffusion of its value and
+// takes Diffusion times the mean of its in bounds neighbours, then the result decays. Scent
+// neither enters nor leaves the cells passable rejects (none if it's nil), so walls block it.
+func (l *Layer) Update(passable func(Coord) bool) {
+	if !l.active {
+		return
+	}
+	minX, maxX := max(l.minX-1, 0), min(l.maxX+1, l.SizeX()-1)
+	minY, maxY := max(l.minY-1, 0), min(l.maxY+1, l.SizeY()-1)
+	keep := 1 - l.Diffusion
+	retain := 1 - l.Decay
+
+	l.active = false
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			if passable != nil && !passable(Coord{X: x, Y: y}) {
+				l.scratch[x][y] = 0
+				continue
+			}
+			sum, count := float32(0), 0
+			for _, d := range [4]Dir{N, E, S, W} {
+				next := Coord{X: x + d.X, Y: y + d.Y}
+				if next.X >= 0 && next.X < l.SizeX() && next.Y >= 0 && next.Y < l.SizeY() && (passable == nil || passable(next)) {
+					sum += l.Data[next.X][next.Y]
+					count++
+				}
+			}
+			mean := l.Data[x][y] // A cell walled in on every side keeps its scent
+			if count > 0 {
+				mean = sum / float32(count)
+			}
+			v := (keep*l.Data[x][y] + l.Diffusion*mean) * retain
+			if v < layerEpsilon {
+				v = 0
+			} else if !l.active {
+				l.minX, l.maxX, l.minY, l.maxY = x, x, y, y
+				l.active = true
+			} else {
+				l.minX, l.maxX = min(l.minX, x), max(l.maxX, x)
+				l.minY, l.maxY = min(l.minY, y), max(l.maxY, y)
+			}
+			l.scratch[x][y] = v
+		}
+	}
+	for x := minX; x <= maxX; x++ {
+		copy(l.Data[x][minY:maxY+1], l.scratch[x][minY:maxY+1])
+	}
+}
+
+func (l *Layer) Clear() {
+	for x := range l.Data {
+		for y := range l.Data[x] {
+			l.Data[x][y] = 0
+		}
+	}
+	l.active = false
+}
@@ -1 +1,216 @@
+// wall.go: Dynamic barriers that move along paths, open and close like doors, or appear for a span of ticks, and the map files that define them.
+
 package grid
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Box is a static wall, covering MinX <= x < MaxX and MinY <= y < MaxY like DrawBox.
+type Box struct {
+	MinX, MinY, MaxX, MaxY int
+}
+
+// Barrier is a rectangle of wall cells that changes with the tick of the generation. Its cells are
+// WALL in the grid while it is in place, so anything that sees walls sees barriers too.
+type Barrier struct {
+	Name          string
+	Width, Height int     // 1 if 0
+	Path          []Coord // Positions of the barrier's bottom left cell, visited in a loop, one cell at a time
+	Speed         int     // Ticks per cell moved along Path, 1 if 0
+	ClosedFor     int     // Doors: ticks in place per cycle, starting closed...
+	OpenFor       int     // ...then ticks gone per cycle. 0 for a barrier that never opens
+	From          int     // First tick the barrier is in place
+	Until         int     // Tick the barrier is removed, 0 for never
+
+	cells []Coord // Cells the barrier has set to WALL
+}
+
+// Cells returns the cells the barrier currently occupies in the grid.
+func (b *Barrier) Cells() []Coord {
+	return b.cells
+}
+
+// InPlace reports whether the barrier should be in the grid at tick.
+func (b *Barrier) InPlace(tick int) bool {
+	if tick < b.From || (b.Until > 0 && tick >= b.Until) {
+		return false
+	}
+	if b.OpenFor > 0 && (tick-b.From)%(b.ClosedFor+b.OpenFor) >= b.ClosedFor {
+		return false
+	}
+	return true
+}
+
+// Position returns the barrier's bottom left cell at tick.
+func (b *Barrier) Position(tick int) Coord {
+	if len(b.Path) == 0 {
+		return Coord{}
+	}
+	length := 0
+	for i := range b.Path {
+		length += chebyshev(b.Path[i], b.Path[(i+1)%len(b.Path)])
+	}
+	if length == 0 || tick < b.From {
+		return b.Path[0]
+	}
+	step := (tick - b.From) / max(b.Speed, 1) % length
+	for i := range b.Path {
+		from, to := b.Path[i], b.Path[(i+1)%len(b.Path)]
+		if d := chebyshev(from, to); step >= d {
+			step -= d
+			continue
+		}
+		return Coord{X: from.X + clampStep(to.X-from.X, step), Y: from.Y + clampStep(to.Y-from.Y, step)}
+	}
+	return b.Path[0]
+}
+
+func chebyshev(a, b Coord) int {
+	return max(abs(a.X-b.X), abs(a.Y-b.Y))
+}
+
+// clampStep moves up to step cells along an axis offset of d.
+func clampStep(d, step int) int {
+	if d < 0 {
+		return -min(-d, step)
+	}
+	return min(d, step)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// cellsAt lists the in bounds cells the barrier should cover at tick.
+func (b *Barrier) cellsAt(g *Grid, tick int) []Coord {
+	if !b.InPlace(tick) {
+		return nil
+	}
+	pos := b.Position(tick)
+	cells := []Coord{}
+	for x := pos.X; x < pos.X+max(b.Width, 1); x++ {
+		for y := pos.Y; y < pos.Y+max(b.Height, 1); y++ {
+			if c := (Coord{X: x, Y: y}); g.IsInBounds(c) {
+				cells = append(cells, c)
+			}
+		}
+	}
+	return cells
+}
+
+// UpdateBarriers moves every barrier to where it should be at tick. A barrier that would land on a
+// creature waits where it is until the creature has moved.
+func (g *Grid) UpdateBarriers(tick int) {
+	if len(g.Barriers) == 0 {
+		return
+	}
+	wanted := make([][]Coord, len(g.Barriers))
+	changed := false
+	for i, b := range g.Barriers {
+		wanted[i] = b.cellsAt(g, tick)
+		for _, c := range wanted[i] {
+			if g.IsOccupiedAt(c) {
+				wanted[i] = b.cells
+				break
+			}
+		}
+		changed = changed || !sameCells(wanted[i], b.cells)
+	}
+	if !changed {
+		return
+	}
+	// Barriers claim the cells they want in order, so overlapping barriers don't leave holes in each
+	// other. Only cells that change are written, so the distance fields of the cells that stay put
+	// are kept.
+	lifted := map[Coord]bool{}
+	for _, b := range g.Barriers {
+		for _, c := range b.cells {
+			lifted[c] = true
+		}
+		b.cells = nil
+	}
+	placed := map[Coord]bool{}
+	for i, b := range g.Barriers {
+		for _, c := range wanted[i] {
+			if !placed[c] && (lifted[c] || g.IsEmptyAt(c)) {
+				g.Set(c, WALL)
+				placed[c] = true
+				b.cells = append(b.cells, c)
+			}
+		}
+	}
+	for c := range lifted {
+		if !placed[c] {
+			g.Set(c, EMPTY)
+		}
+	}
+}
+
+func sameCells(a, b []Coord) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// BarrierCells returns the cells of every barrier currently in the grid.
+func (g *Grid) BarrierCells() []Coord {
+	cells := []Coord{}
+	for _, b := range g.Barriers {
+		cells = append(cells, b.cells...)
+	}
+	return cells
+}
+
+// MapFile is a map read from a JSON file: a map type for the base walls, extra static walls, and barriers.
+type MapFile struct {
+	Type     string // Name or number of a MapType, MIDDLE_WALL if empty
+	Walls    []Box
+	Barriers []*Barrier
+}
+
+// LoadMap reads a map file.
+func LoadMap(path string) (*MapFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &MapFile{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("reading map %s: %w", path, err)
+	}
+	for i, b := range m.Barriers {
+		if len(b.Path) == 0 {
+			return nil, fmt.Errorf("reading map %s: barrier %d (%s) has no path", path, i, b.Name)
+		}
+	}
+	return m, nil
+}
+
+// ApplyMap replaces the grid's walls and barriers with the map's, and clears the grid.
+func (g *Grid) ApplyMap(m *MapFile) error {
+	g.Type = MIDDLE_WALL
+	if m.Type != "" {
+		t, err := ParseMapType(m.Type)
+		if err != nil {
+			return err
+		}
+		g.Type = t
+	}
+	g.Walls = m.Walls
+	g.Barriers = m.Barriers
+	g.ZeroFill()
+	g.CreateWall()
+	return nil
+}
@@ -0,0 +1,126 @@
+// colors.go: The colour modes creatures can be drawn in, from their genome, species, lineage, traits or state.
+
+package render
+
+import (
+	"biogo/v2/simulation"
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+type ColorMode int
+
+const (
+	ColorGenome ColorMode = iota // Genome.ToColor, from three of the brain's genes
+	ColorSpecies
+	ColorLineage // The first generation genome the creature descends from
+	ColorMutationRate
+	ColorSightDistance
+	ColorResponsiveness
+	ColorEnergy
+	ColorAge
+	ColorBrainSize
+	ColorAction // The strongest action on the creature's last step
+	ColorModeCount
+)
+
+var colorModeNames = []string{"genome", "species", "lineage", "mutation-rate", "sight-distance", "responsiveness", "energy", "age", "brain-size", "action"}
+
+func (m ColorMode) String() string {
+	if m >= 0 && int(m) < len(colorModeNames) {
+		return colorModeNames[m]
+	}
+	return fmt.Sprintf("ColorMode(%d)", m)
+}
+
+func ParseColorMode(s string) (ColorMode, error) {
+	for i, name := range colorModeNames {
+		if strings.EqualFold(s, name) {
+			return ColorMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown colour mode %q, want one of %s", s, strings.Join(colorModeNames, ", "))
+}
+
+// Next is the mode after m, wrapping round to the first.
+func (m ColorMode) Next() ColorMode {
+	return (m + 1) % ColorModeCount
+}
+
+// Unclassified is the colour of a creature with no category in a categorical mode, such as a
+// species past MaxSpeciesIDs.
+var Unclassified = color.RGBA{128, 128, 128, 255}
+
+// Color is the creature's colour in the mode. Species, lineage and action modes give each category
+// its own hue, the others shade from dark blue for low values to yellow for high ones.
+func (m ColorMode) Color(c *simulation.Creature) color.RGBA {
+	switch m {
+	case ColorSpecies:
+		if c.Population == nil {
+			return Unclassified
+		}
+		return categoryColor(c.Population.SpeciesID(c))
+	case ColorLineage:
+		return categoryColor(c.Genome.Lineage - 1)
+	case ColorMutationRate:
+		return scaleColor(float64(c.Genome.MutationRate) / math.MaxUint8)
+	case ColorSightDistance:
+		return scaleColor(float64(c.Genome.SightDistance) / math.MaxUint8)
+	case ColorResponsiveness:
+		return scaleColor(float64(c.Genome.Responsiveness) / math.MaxUint8)
+	case ColorEnergy:
+		return scaleColor(float64(c.Energy) / float64(c.Genome.MaxEnergy))
+	case ColorAge:
+		return scaleColor(float64(c.Age) / float64(simulation.Params.MaxAge))
+	case ColorBrainSize:
+		return scaleColor(float64(len(c.Nnet.Edges)) / float64(simulation.Params.MaxStartNeuronCount))
+	case ColorAction:
+		return categoryColor(c.DominantAction())
+	}
+	r, g, b, a := c.Genome.ToColor()
+	return color.RGBA{r, g, b, a}
+}
+
+// categoryColor gives category i a hue a golden angle round from category i-1's, so neighbouring
+// categories stand apart. Negative categories are Unclassified.
+func categoryColor(i int) color.RGBA {
+	if i < 0 {
+		return Unclassified
+	}
+	return hsv(math.Mod(float64(i)*137.508, 360), 0.75, 1)
+}
+
+// scaleColor shades v, clamped to [0, 1], from dark blue through to yellow.
+func scaleColor(v float64) color.RGBA {
+	if math.IsNaN(v) {
+		v = 0
+	}
+	v = math.Max(0, math.Min(1, v))
+	low, high := color.RGBA{40, 50, 160, 255}, color.RGBA{250, 220, 40, 255}
+	mix := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*v) }
+	return color.RGBA{mix(low.R, high.R), mix(low.G, high.G), mix(low.B, high.B), 255}
+}
+
+func hsv(h, s, v float64) color.RGBA {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = c, x
+	case h < 120:
+		r, g = x, c
+	case h < 180:
+		g, b = c, x
+	case h < 240:
+		g, b = x, c
+	case h < 300:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := v - c
+	return color.RGBA{uint8((r + m) * 255), uint8((g + m) * 255), uint8((b + m) * 255), 255}
+}
@@ -0,0 +1,91 @@
+// frame.go: Draws the grid to an image without Ebiten, in the colours the UI uses.
+
+// Package render draws the simulation to plain images, so runs can be recorded headlessly.
+package render
+
+import (
+	"biogo/v2/grid"
+	"biogo/v2/simulation"
+	"image"
+	"image/color"
+)
+
+var (
+	Background    = color.RGBA{15, 15, 15, 255}
+	ZoneColor     = color.RGBA{25, 45, 25, 255} // The challenge zone, a dim green under the creatures
+	ZoneEdgeColor = color.RGBA{60, 120, 60, 255}
+	WallColor     = color.RGBA{255, 255, 255, 255}
+	BarrierColor  = color.RGBA{255, 165, 0, 255} // Moving barriers, orange as in the UI
+)
+
+// Frame draws the grid with each cell scale pixels square: the terrain, the barriers where they
+// are this tick, then the living creatures coloured by mode.
+func Frame(sim *simulation.Simulation, scale int, mode ColorMode) *image.RGBA {
+	img := Terrain(sim, scale)
+	for _, loc := range sim.Grid.BarrierCells() {
+		cell(img, loc, scale, BarrierColor)
+	}
+	for _, c := range sim.Creatures() {
+		if c.Alive {
+			cell(img, c.Loc, scale, mode.Color(c))
+		}
+	}
+	return img
+}
+
+// Terrain draws what stays put through a generation: the zones of the populations' challenges,
+// edged so their boundary shows, and the static walls.
+func Terrain(sim *simulation.Simulation, scale int) *image.RGBA {
+	g := sim.Grid
+	img := image.NewRGBA(image.Rect(0, 0, g.SizeX()*scale, g.SizeY()*scale))
+	fill(img, img.Bounds(), Background)
+
+	zone := make([][]bool, g.SizeX())
+	for x := range zone {
+		zone[x] = make([]bool, g.SizeY())
+	}
+	drawn := map[simulation.ChallengeType]bool{}
+	for _, pop := range sim.Populations {
+		if drawn[pop.Config.Challenge] {
+			continue
+		}
+		drawn[pop.Config.Challenge] = true
+		for _, loc := range pop.Config.Challenge.Zone(g) {
+			zone[loc.X][loc.Y] = true
+		}
+	}
+	for x := range zone {
+		for y := range zone[x] {
+			if !zone[x][y] {
+				continue
+			}
+			loc := grid.Coord{X: x, Y: y}
+			clr := ZoneColor
+			for _, dir := range []grid.Dir{grid.N, grid.E, grid.S, grid.W} {
+				next := grid.Coord{X: x + dir.X, Y: y + dir.Y}
+				if g.IsInBounds(next) && !zone[next.X][next.Y] {
+					clr = ZoneEdgeColor
+					break
+				}
+			}
+			cell(img, loc, scale, clr)
+		}
+	}
+
+	for _, loc := range g.WallLocations {
+		cell(img, loc, scale, WallColor)
+	}
+	return img
+}
+
+func cell(img *image.RGBA, loc grid.Coord, scale int, c color.RGBA) {
+	fill(img, image.Rect(loc.X*scale, loc.Y*scale, (loc.X+1)*scale, (loc.Y+1)*scale), c)
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// recorder.go: Records chosen generations frame by frame, as animated GIFs or directories of numbered PNGs.
+
+package render
+
+import (
+	"biogo/v2/simulation"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	GIF = "gif" // An animation per generation, generation-N.gif
+	PNG = "png" // A directory of frames per generation, generation-N/frame-NNNN.png
+)
+
+// Recorder captures frames of the generations it records and writes each one out when it ends.
+// Generations are numbered from 1, as the simulation prints them.
+type Recorder struct {
+	Dir         string
+	Format      string
+	Scale       int // Pixels per cell
+	Mode        ColorMode
+	Every       int   // Record generations 1, Every+1, 2*Every+1 and so on
+	Generations []int // Record just these generations instead, if set
+	TickStep    int   // Ticks between frames
+	Delay       int   // Between GIF frames, in hundredths of a second
+
+	frames []*image.RGBA
+}
+
+// NewRecorder returns a recorder writing to dir with defaults for everything else: every 50th
+// generation at a frame every 5 ticks.
+func NewRecorder(dir, format string) (*Recorder, error) {
+	if format != GIF && format != PNG {
+		return nil, fmt.Errorf("unknown format %q, want %s or %s", format, GIF, PNG)
+	}
+	return &Recorder{Dir: dir, Format: format, Scale: 1, Every: 50, TickStep: 5, Delay: 4}, nil
+}
+
+// Records reports whether the generation is one to record.
+func (r *Recorder) Records(generation int) bool {
+	if len(r.Generations) > 0 {
+		return slices.Contains(r.Generations, generation)
+	}
+	return r.Every > 0 && (generation-1)%r.Every == 0
+}
+
+// Capture keeps a frame of the simulation if its generation is recorded and the tick is on the
+// frame step. The last tick of a generation is always kept, to show who survived.
+func (r *Recorder) Capture(sim *simulation.Simulation) {
+	if !r.Records(sim.Generation + 1) {
+		return
+	}
+	if sim.Tick%max(r.TickStep, 1) == 0 || sim.Tick == simulation.Params.MaxAge {
+		r.frames = append(r.frames, Frame(sim, max(r.Scale, 1), r.Mode))
+	}
+}
+
+// RunGeneration runs the simulation's current generation like Simulation.RunGeneration, capturing
+// and writing its frames if it's recorded.
+func (r *Recorder) RunGeneration(sim *simulation.Simulation) error {
+	generation := sim.Generation + 1
+	if !r.Records(generation) {
+		sim.RunGeneration()
+		return nil
+	}
+	r.Capture(sim)
+	for sim.Tick < simulation.Params.MaxAge {
+		sim.Step()
+		r.Capture(sim)
+	}
+	// Write before breeding, which clears the grid
+	if err := r.Flush(generation); err != nil {
+		return err
+	}
+	sim.InitializeNewGeneration()
+	return nil
+}
+
+// Flush writes the captured frames as the given generation and forgets them.
+func (r *Recorder) Flush(generation int) error {
+	frames := r.frames
+	r.frames = nil
+	if len(frames) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("generation-%d", generation)
+	if r.Format == PNG {
+		return writePNGs(filepath.Join(r.Dir, name), frames)
+	}
+	return writeGIF(filepath.Join(r.Dir, name+".gif"), frames, r.Delay)
+}
+
+func writePNGs(dir string, frames []*image.RGBA) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for i, frame := range frames {
+		if err := writeFile(filepath.Join(dir, fmt.Sprintf("frame-%04d.png", i+1)), func(f *os.File) error {
+			return png.Encode(f, frame)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gifPalette keeps the grid's own colours exact, and fits the creatures to the Plan 9 palette.
+var gifPalette = append(color.Palette{Background, ZoneColor, ZoneEdgeColor, WallColor, BarrierColor}, palette.Plan9[:251]...)
+
+func writeGIF(path string, frames []*image.RGBA, delay int) error {
+	anim := &gif.GIF{}
+	for _, frame := range frames {
+		paletted := image.NewPaletted(frame.Bounds(), gifPalette)
+		draw.Draw(paletted, frame.Bounds(), frame, frame.Bounds().Min, draw.Src)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return writeFile(path, func(f *os.File) error {
+		return gif.EncodeAll(f, anim)
+	})
+}
+
+func writeFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"biogo/v2/grid"
+	"biogo/v2/simulation"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func smallWorld(challenge simulation.ChallengeType) func() {
+	saved := *simulation.Params
+	simulation.Params.GridWidth, simulation.Params.GridHeight = 60, 40
+	simulation.Params.MaxPopulation, simulation.Params.StartingPopulation = 50, 50
+	simulation.Params.MaxAge = 20
+	simulation.Params.Challenge = challenge
+	return func() { *simulation.Params = saved }
+}
+
+func TestFrame(t *testing.T) {
+	defer smallWorld(simulation.LeftSurvive)()
+	sim := simulation.New()
+	// The creatures are already placed, so put the wall where there isn't one
+	wall := grid.Coord{X: 58, Y: 1}
+	for sim.CreatureAt(wall) != nil {
+		wall.Y++
+	}
+	sim.Grid.DrawBox(wall.X, wall.Y, wall.X+1, wall.Y+1)
+	img := Frame(sim, 3, ColorGenome)
+	if b := img.Bounds(); b.Dx() != 180 || b.Dy() != 120 {
+		t.Fatalf("Frame is %v, want 180x120", b)
+	}
+
+	at := func(loc grid.Coord) color.RGBA {
+		return img.RGBAAt(loc.X*3+1, loc.Y*3+1)
+	}
+	c := sim.Creatures()[0]
+	r, g, b, a := c.Genome.ToColor()
+	if got := at(c.Loc); got != (color.RGBA{r, g, b, a}) {
+		t.Errorf("Creature drawn as %v, want its genome colour", got)
+	}
+	if got := at(wall); got != WallColor {
+		t.Errorf("Wall drawn as %v, want %v", got, WallColor)
+	}
+	// LeftSurvive's zone is x < 30
+	for loc, want := range map[grid.Coord]color.RGBA{{X: 1, Y: 0}: ZoneColor, {X: 29, Y: 0}: ZoneEdgeColor, {X: 30, Y: 0}: Background, {X: 59, Y: 0}: Background} {
+		if sim.CreatureAt(loc) != nil {
+			continue
+		}
+		if got := at(loc); got != want {
+			t.Errorf("Empty cell %v drawn as %v, want %v", loc, got, want)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	defer smallWorld(simulation.AllSurvive)()
+	sim := simulation.New()
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, GIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Every, rec.TickStep = 2, 3
+	for sim.Generation < 3 {
+		if err := rec.RunGeneration(sim); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Ticks 0, 3, ... 18 and the last, 20
+	f, err := os.Open(filepath.Join(dir, "generation-1.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	anim, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Image) != 8 {
+		t.Errorf("Recorded %d frames, want 8", len(anim.Image))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "generation-2.gif")); !os.IsNotExist(err) {
+		t.Error("Generation 2 shouldn't be recorded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "generation-3.gif")); err != nil {
+		t.Error("Generation 3 should be recorded")
+	}
+
+	rec.Format, rec.Generations = PNG, []int{4}
+	if err := rec.RunGeneration(sim); err != nil {
+		t.Fatal(err)
+	}
+	frames, _ := filepath.Glob(filepath.Join(dir, "generation-4", "frame-*.png"))
+	if len(frames) != 8 {
+		t.Errorf("Wrote %d PNG frames, want 8", len(frames))
+	}
+
+	if _, err := NewRecorder(dir, "mp4"); err == nil {
+		t.Error("An unknown format should be an error")
+	}
+}
+
+func TestColorModes(t *testing.T) {
+	defer smallWorld(simulation.AllSurvive)()
+	sim := simulation.New()
+	c := sim.Creatures()[0]
+	for m := ColorMode(0); m < ColorModeCount; m++ {
+		if parsed, err := ParseColorMode(m.String()); err != nil || parsed != m {
+			t.Errorf("ParseColorMode(%q) = %v, %v", m.String(), parsed, err)
+		}
+		if got := m.Color(c); got.A != 255 {
+			t.Errorf("%v colour %v should be opaque", m, got)
+		}
+	}
+	if _, err := ParseColorMode("plaid"); err == nil {
+		t.Error("An unknown mode should be an error")
+	}
+	if ColorAction.Color(c) != Unclassified {
+		t.Error("A creature that hasn't stepped has no dominant action")
+	}
+
+	low, high := *c, *c
+	low.Age, high.Age = 0, simulation.Params.MaxAge
+	if ColorAge.Color(&low) == ColorAge.Color(&high) {
+		t.Error("Age should shade newborns and the oldest differently")
+	}
+	other := sim.Creatures()[1]
+	if ColorLineage.Color(c) == ColorLineage.Color(other) {
+		t.Error("Separate lineages should have separate colours")
+	}
+	clone := *other
+	clone.Genome = c.Genome.Copy()
+	if ColorLineage.Color(c) != ColorLineage.Color(&clone) {
+		t.Error("A copied genome should keep its lineage's colour")
+	}
+}
+
+func TestGIFPaletteKeepsGridColours(t *testing.T) {
+	if len(gifPalette) != 256 {
+		t.Errorf("GIF palette has %d colours, want 256", len(gifPalette))
+	}
+	for _, c := range []color.RGBA{Background, ZoneColor, ZoneEdgeColor, WallColor, BarrierColor} {
+		if got := gifPalette.Convert(c); got != c {
+			t.Errorf("%v quantized to %v", c, got)
+		}
+	}
+}
@@ -14,13 +14,11 @@ const (
 	MOVE_WEST
 	MOVE_NORTH
 	MOVE_SOUTH
+	EMIT_PHEROMONE
+	KILL_FORWARD
 
-	ACTION_COUNT
+	ACTION_COUNT // Built in actions, more can be added with RegisterAction
 	// Disabled for now
 	REPRODUCE
 	EAT
 )
-
-func IsActionEnabled(a byte) bool {
-	return a < ACTION_COUNT
-}
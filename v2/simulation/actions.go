@@ -2,87 +2,110 @@
 
 package simulation
 
-// Action logic for Simulation.ExecuteActions split out for clarity and maintainability.
 import (
 	"biogo/v2/grid"
 	"biogo/v2/utils"
 	"math"
+	"math/rand"
 )
 
-func (s *Simulation) handleResponsiveness(c *Creature, actionLevels []float32) {
-	if IsActionEnabled(SET_RESPONSIVENESS) {
-		level := actionLevels[SET_RESPONSIVENESS]
-		level = (float32(math.Tanh(float64(level/float32(utils.ClampByteAsFloat32(0, 1, c.Genome.Responsiveness))))) + 1) / 2
-		c.Responsiveness = level
-	}
+var actions = []*Action{
+	MOVE_X:                {Name: "MOVE_X", Code: "MvX", Enabled: true, Execute: moveX},
+	MOVE_Y:                {Name: "MOVE_Y", Code: "MvY", Enabled: true, Execute: moveY},
+	MOVE_FWD:              {Name: "MOVE_FWD", Code: "Mfd", Enabled: true, Execute: moveForward},
+	MOVE_RL:               {Name: "MOVE_RL", Code: "Mrl", Enabled: true, Execute: moveRL},
+	MOVE_LEFT:             {Name: "MOVE_LEFT", Code: "MvL", Enabled: true, Execute: moveLeft},
+	MOVE_RIGHT:            {Name: "MOVE_RIGHT", Code: "MvR", Enabled: true, Execute: moveRight},
+	MOVE_RANDOM:           {Name: "MOVE_RANDOM", Code: "Mrn", Enabled: true, Execute: moveRandom},
+	SET_OSCILLATOR_PERIOD: {Name: "SET_OSCILLATOR_PERIOD", Code: "OSC", Enabled: true, Execute: setOscillatorPeriod},
+	SET_RESPONSIVENESS:    {Name: "SET_RESPONSIVENESS", Code: "Res", Enabled: true, Execute: setResponsiveness},
+	MOVE_EAST:             {Name: "MOVE_EAST", Code: "MvE", Enabled: true, Execute: moveEast},
+	MOVE_WEST:             {Name: "MOVE_WEST", Code: "MvW", Enabled: true, Execute: moveWest},
+	MOVE_NORTH:            {Name: "MOVE_NORTH", Code: "MvN", Enabled: true, Execute: moveNorth},
+	MOVE_SOUTH:            {Name: "MOVE_SOUTH", Code: "MvS", Enabled: true, Execute: moveSouth},
+	EMIT_PHEROMONE:        {Name: "EMIT_PHEROMONE", Code: "EmP", Enabled: false, Execute: emitPheromone},
+	KILL_FORWARD:          {Name: "KILL_FORWARD", Code: "Kil", Enabled: false, Execute: killForward},
 }
 
-func (s *Simulation) handleOscillatorPeriod(c *Creature, actionLevels []float32) {
-	if IsActionEnabled(SET_OSCILLATOR_PERIOD) {
-		periodf := actionLevels[SET_OSCILLATOR_PERIOD]
-		newPeriodf := float32(math.Tanh(float64(periodf)+1) / 2)
-		newPeriod := 1 + int(1.5+math.Exp(7*float64(newPeriodf)))
-		if newPeriod >= 2 && newPeriod <= math.MaxUint8 {
-			c.Clock = newPeriod
-		}
-	}
+func setResponsiveness(s *Simulation, c *Creature, level float32, move *Movement) {
+	level = (float32(math.Tanh(float64(level/float32(utils.ClampByteAsFloat32(0, 1, c.Genome.Responsiveness))))) + 1) / 2
+	c.Responsiveness = level
 }
 
-func (s *Simulation) handleMovement(c *Creature, actionLevels []float32, responseAdjust float32) (float32, float32) {
-	moveX, moveY := float32(0), float32(0)
-	if IsActionEnabled(MOVE_X) {
-		moveX = actionLevels[MOVE_X]
-	}
-	if IsActionEnabled(MOVE_Y) {
-		moveX = actionLevels[MOVE_Y]
-	}
-	if IsActionEnabled(MOVE_EAST) {
-		moveX += actionLevels[MOVE_EAST]
-	}
-	if IsActionEnabled(MOVE_WEST) {
-		moveX -= actionLevels[MOVE_WEST]
-	}
-	if IsActionEnabled(MOVE_NORTH) {
-		moveY += actionLevels[MOVE_NORTH]
-	}
-	if IsActionEnabled(MOVE_SOUTH) {
-		moveY += actionLevels[MOVE_SOUTH]
-	}
-	if IsActionEnabled(MOVE_FWD) {
-		level := actionLevels[MOVE_FWD]
-		moveX += float32(c.LastMoveDir.X) * level
-		moveY += float32(c.LastMoveDir.Y) * level
-	}
-	if IsActionEnabled(MOVE_LEFT) {
-		level := actionLevels[MOVE_LEFT]
-		offset := c.LastMoveDir.Rotate90CCW()
-		moveX += float32(offset.X) * level
-		moveY += float32(offset.Y) * level
+func setOscillatorPeriod(s *Simulation, c *Creature, periodf float32, move *Movement) {
+	newPeriodf := float32(math.Tanh(float64(periodf)+1) / 2)
+	newPeriod := 1 + int(1.5+math.Exp(7*float64(newPeriodf)))
+	if newPeriod >= 2 && newPeriod <= math.MaxUint8 {
+		c.Clock = newPeriod
 	}
-	if IsActionEnabled(MOVE_RIGHT) {
-		level := actionLevels[MOVE_RIGHT]
-		offset := c.LastMoveDir.Rotate90CW()
-		moveX += float32(offset.X) * level
-		moveY += float32(offset.Y) * level
+}
+
+// killForward attacks the creature in front, with a probability set by the action level. An attack
+// kills with probability Params.KillProbability; the death happens in ProcessDeathQueue.
+func killForward(s *Simulation, c *Creature, level float32, move *Movement) {
+	if prob2Bool(math.Tanh(float64(level))) == 0 || rand.Float32() >= Params.KillProbability {
+		return
 	}
-	if IsActionEnabled(MOVE_RL) {
-		level := actionLevels[MOVE_RL]
-		offset := grid.CENTER
-		if level < 0 {
-			offset = c.LastMoveDir.Rotate90CCW()
-		} else if level > 0 {
-			offset = c.LastMoveDir.Rotate90CW()
-		}
-		moveX += float32(offset.X) * level
-		moveY += float32(offset.Y) * level
+	target := s.CreatureAt(c.GetNextLoc(c.LastMoveDir))
+	if target != nil && target != c && target.Alive {
+		target.Population.QueueForDeath(target, c)
 	}
-	if IsActionEnabled(MOVE_RANDOM) {
-		level := actionLevels[MOVE_RANDOM]
-		offset := grid.RandomDir()
-		moveX += float32(offset.X) * level
-		moveY += float32(offset.Y) * level
+}
+
+func moveX(s *Simulation, c *Creature, level float32, move *Movement) {
+	move.X += level
+}
+
+func moveY(s *Simulation, c *Creature, level float32, move *Movement) {
+	move.Y += level
+}
+
+func moveEast(s *Simulation, c *Creature, level float32, move *Movement) {
+	move.X += level
+}
+
+func moveWest(s *Simulation, c *Creature, level float32, move *Movement) {
+	move.X -= level
+}
+
+func moveNorth(s *Simulation, c *Creature, level float32, move *Movement) {
+	move.Y += level
+}
+
+func moveSouth(s *Simulation, c *Creature, level float32, move *Movement) {
+	move.Y -= level
+}
+
+func moveForward(s *Simulation, c *Creature, level float32, move *Movement) {
+	move.X += float32(c.LastMoveDir.X) * level
+	move.Y += float32(c.LastMoveDir.Y) * level
+}
+
+func moveLeft(s *Simulation, c *Creature, level float32, move *Movement) {
+	offset := c.LastMoveDir.Rotate90CCW()
+	move.X += float32(offset.X) * level
+	move.Y += float32(offset.Y) * level
+}
+
+func moveRight(s *Simulation, c *Creature, level float32, move *Movement) {
+	offset := c.LastMoveDir.Rotate90CW()
+	move.X += float32(offset.X) * level
+	move.Y += float32(offset.Y) * level
+}
+
+func moveRL(s *Simulation, c *Creature, level float32, move *Movement) {
+	offset := grid.CENTER
+	if level < 0 {
+		offset = c.LastMoveDir.Rotate90CCW()
+	} else if level > 0 {
+		offset = c.LastMoveDir.Rotate90CW()
 	}
-	moveX = float32(math.Tanh(float64(moveX))) * responseAdjust
-	moveY = float32(math.Tanh(float64(moveY))) * responseAdjust
-	return moveX, moveY
+	move.X += float32(offset.X) * level
+	move.Y += float32(offset.Y) * level
+}
+
+func moveRandom(s *Simulation, c *Creature, level float32, move *Movement) {
+	offset := grid.RandomDir()
+	move.X += float32(offset.X) * level
+	move.Y += float32(offset.Y) * level
 }
@@ -0,0 +1,152 @@
+// activation.go: Activation functions for hidden neurons, the order they're evaluated in, and how their outputs carry over between steps.
+
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type ActivationType int
+
+const (
+	ActivationTanh ActivationType = iota
+	ActivationReLU
+	ActivationSigmoid
+	ActivationStep
+	ActivationIdentity
+
+	ACTIVATION_COUNT
+	// ActivationFromGenome lets each creature's genome choose one of the above
+	ActivationFromGenome
+)
+
+type RecurrenceMode int
+
+const (
+	// RecurrenceStateful keeps neuron outputs between steps, so a neuron reading another (or itself)
+	// sees the previous step's output.
+	RecurrenceStateful RecurrenceMode = iota
+	// RecurrenceStateless resets neuron outputs to CreateInitialNeuronOutput at the start of every step.
+	RecurrenceStateless
+)
+
+type EvaluationMode int
+
+const (
+	// EvaluationBatched sums every edge into the hidden neurons before updating any of them, so a neuron
+	// fed by another neuron always sees that neuron's output from the previous step.
+	EvaluationBatched EvaluationMode = iota
+	// EvaluationTopological updates neurons in dependency order, so chains of neurons settle within a
+	// single step. Only edges that close a cycle read the previous step's output.
+	EvaluationTopological
+)
+
+var activationNames = [ACTIVATION_COUNT]string{"tanh", "relu", "sigmoid", "step", "identity"}
+
+func (a ActivationType) String() string {
+	if a >= 0 && a < ACTIVATION_COUNT {
+		return activationNames[a]
+	}
+	if a == ActivationFromGenome {
+		return "genome"
+	}
+	return "unknown"
+}
+
+// ParseActivation reads an activation by name (case insensitive), "genome" or number.
+func ParseActivation(s string) (ActivationType, error) {
+	for i, name := range activationNames {
+		if strings.EqualFold(s, name) {
+			return ActivationType(i), nil
+		}
+	}
+	if strings.EqualFold(s, "genome") {
+		return ActivationFromGenome, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && ((n >= 0 && n < int(ACTIVATION_COUNT)) || n == int(ActivationFromGenome)) {
+		return ActivationType(n), nil
+	}
+	return 0, fmt.Errorf("unknown activation %q", s)
+}
+
+var recurrenceNames = []string{"stateful", "stateless"}
+
+func (r RecurrenceMode) String() string {
+	if int(r) >= 0 && int(r) < len(recurrenceNames) {
+		return recurrenceNames[r]
+	}
+	return fmt.Sprintf("RecurrenceMode(%d)", int(r))
+}
+
+// ParseRecurrence reads a recurrence mode by name (case insensitive) or number.
+func ParseRecurrence(s string) (RecurrenceMode, error) {
+	n, err := parseEnum(s, recurrenceNames)
+	if err != nil {
+		return 0, fmt.Errorf("unknown recurrence mode %q", s)
+	}
+	return RecurrenceMode(n), nil
+}
+
+var evaluationNames = []string{"batched", "topological"}
+
+func (e EvaluationMode) String() string {
+	if int(e) >= 0 && int(e) < len(evaluationNames) {
+		return evaluationNames[e]
+	}
+	return fmt.Sprintf("EvaluationMode(%d)", int(e))
+}
+
+// ParseEvaluation reads an evaluation mode by name (case insensitive) or number.
+func ParseEvaluation(s string) (EvaluationMode, error) {
+	n, err := parseEnum(s, evaluationNames)
+	if err != nil {
+		return 0, fmt.Errorf("unknown evaluation mode %q", s)
+	}
+	return EvaluationMode(n), nil
+}
+
+// parseEnum finds s among names, case insensitively, or reads it as the index of one.
+func parseEnum(s string, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(names) {
+		return n, nil
+	}
+	return 0, fmt.Errorf("unknown value %q", s)
+}
+
+// Activate applies the activation function to a neuron's summed input.
+func (a ActivationType) Activate(x float32) float32 {
+	switch a {
+	case ActivationReLU:
+		if x < 0 {
+			return 0
+		}
+		return x
+	case ActivationSigmoid:
+		return float32(1 / (1 + math.Exp(-float64(x))))
+	case ActivationStep:
+		if x > 0 {
+			return 1
+		}
+		return 0
+	case ActivationIdentity:
+		return x
+	default:
+		return float32(math.Tanh(float64(x)))
+	}
+}
+
+// ActivationFunction is the activation the genome's neurons use under the current Params.
+func (g Genome) ActivationFunction() ActivationType {
+	if Params.Activation == ActivationFromGenome {
+		return ActivationType(g.Activation % byte(ACTIVATION_COUNT))
+	}
+	return Params.Activation
+}
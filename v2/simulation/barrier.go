@@ -0,0 +1,54 @@
+// barrier.go: Sensors for walls and barriers, which unlike the sight sensors don't confuse them with creatures.
+
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"biogo/v2/utils"
+)
+
+// barrierDistanceAlong returns how far along dir the nearest barrier within sight is, as a fraction
+// of the creature's sight distance. It's 1 when there's no barrier in sight; creatures and grid edges
+// don't block the view.
+func barrierDistanceAlong(c *Creature, g *grid.Grid, dir grid.Dir) float32 {
+	sight := int(c.Genome.SightDistance)
+	if dir == grid.CENTER || sight == 0 {
+		return 1
+	}
+	// Nothing to look for when the nearest barrier in any direction is out of sight
+	if d := g.BarrierDistance().At(c.Loc); d == grid.Unreachable || d > sight {
+		return 1
+	}
+	loc := c.Loc
+	for free := 0; free < sight; free++ {
+		loc = grid.Coord{X: loc.X + dir.X, Y: loc.Y + dir.Y}
+		if !g.IsInBounds(loc) {
+			return 1
+		}
+		if g.IsBarrierAt(loc) {
+			return float32(free) / float32(sight)
+		}
+	}
+	return 1
+}
+
+func senseBarrierForward(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return barrierDistanceAlong(c, g, c.LastMoveDir)
+}
+
+// senseBarrierLR is 0.5 when barriers are as near on both sides, above when the nearest is to the right.
+func senseBarrierLR(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	right := barrierDistanceAlong(c, g, c.LastMoveDir.Rotate90CW())
+	left := barrierDistanceAlong(c, g, c.LastMoveDir.Rotate90CCW())
+	return 0.5 + (left-right)/2
+}
+
+// senseBarrierDist is the distance to the nearest barrier in any direction, scaled like BOUNDARY_DIST.
+func senseBarrierDist(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	d := g.BarrierDistance().At(c.Loc)
+	if d == grid.Unreachable {
+		return 1
+	}
+	maxPossible := utils.Max(Params.GridWidth/2-1, Params.GridHeight/2-1)
+	return float32(d) / float32(maxPossible)
+}
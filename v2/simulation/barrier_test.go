@@ -0,0 +1,180 @@
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBarrier_DoorsAndPaths(t *testing.T) {
+	g := grid.NewGrid(30, 30, int(grid.NO_WALLS))
+	door := &grid.Barrier{Name: "door", Height: 2, Path: []grid.Coord{{X: 5, Y: 5}}, ClosedFor: 2, OpenFor: 3}
+	mover := &grid.Barrier{Name: "mover", Path: []grid.Coord{{X: 10, Y: 10}, {X: 12, Y: 10}}, Speed: 2}
+	late := &grid.Barrier{Name: "late", Path: []grid.Coord{{X: 20, Y: 20}}, From: 3, Until: 5}
+	if err := g.ApplyMap(&grid.MapFile{Type: "NO_WALLS", Barriers: []*grid.Barrier{door, mover, late}}); err != nil {
+		t.Fatal(err)
+	}
+
+	doorClosed := []bool{true, true, false, false, false, true, true, false}
+	moverX := []int{10, 10, 11, 11, 12, 12, 11, 11}
+	for tick := range doorClosed {
+		g.UpdateBarriers(tick)
+		if got := g.IsBarrierAt(grid.Coord{X: 5, Y: 6}); got != doorClosed[tick] {
+			t.Errorf("tick %d: door closed = %v, want %v", tick, got, doorClosed[tick])
+		}
+		if len(mover.Cells()) != 1 || mover.Cells()[0].X != moverX[tick] {
+			t.Errorf("tick %d: mover at %v, want x=%d", tick, mover.Cells(), moverX[tick])
+		}
+		if got, want := g.IsBarrierAt(grid.Coord{X: 20, Y: 20}), tick >= 3 && tick < 5; got != want {
+			t.Errorf("tick %d: late barrier in place = %v, want %v", tick, got, want)
+		}
+	}
+	if n := len(g.BarrierCells()); n != 1 {
+		t.Errorf("BarrierCells has %d cells with only the mover in place, want 1", n)
+	}
+	// Cells a barrier leaves are emptied
+	for x := 10; x <= 12; x++ {
+		if c := (grid.Coord{X: x, Y: 10}); x != moverX[len(moverX)-1] && !g.IsEmptyAt(c) {
+			t.Errorf("%v should be empty once the mover has left", c)
+		}
+	}
+}
+
+func TestBarrier_WaitsForCreatures(t *testing.T) {
+	g := grid.NewGrid(30, 30, int(grid.NO_WALLS))
+	door := &grid.Barrier{Path: []grid.Coord{{X: 5, Y: 5}}, ClosedFor: 1, OpenFor: 1}
+	if err := g.ApplyMap(&grid.MapFile{Type: "NO_WALLS", Barriers: []*grid.Barrier{door}}); err != nil {
+		t.Fatal(err)
+	}
+	g.UpdateBarriers(1)
+	g.Set(grid.Coord{X: 5, Y: 5}, grid.RESERVED_CELL_TYPES)
+	g.UpdateBarriers(2)
+	if g.At(grid.Coord{X: 5, Y: 5}) != grid.RESERVED_CELL_TYPES {
+		t.Fatal("The door shouldn't close on a creature")
+	}
+	g.Set(grid.Coord{X: 5, Y: 5}, grid.EMPTY)
+	g.UpdateBarriers(4)
+	if !g.IsBarrierAt(grid.Coord{X: 5, Y: 5}) {
+		t.Error("The door should close once the creature has left")
+	}
+}
+
+func TestBarrier_MapFile(t *testing.T) {
+	defer smallWorld()()
+	path := filepath.Join(t.TempDir(), "map.json")
+	data := `{"Type": "NO_WALLS", "Walls": [{"MinX": 0, "MinY": 0, "MaxX": 60, "MaxY": 2}],
+		"Barriers": [{"Name": "sweeper", "Width": 3, "Height": 10, "Path": [{"X": 30, "Y": 5}, {"X": 30, "Y": 25}]}]}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	Params.MapFile = path
+	sim := New()
+
+	if len(sim.Grid.Barriers) != 1 || len(sim.Grid.BarrierCells()) != 30 {
+		t.Fatalf("The map's barrier should cover 30 cells, got %d", len(sim.Grid.BarrierCells()))
+	}
+	if !sim.Grid.IsBarrierAt(grid.Coord{X: 10, Y: 1}) {
+		t.Error("The map's static walls should be in the grid")
+	}
+	for _, c := range sim.Creatures() {
+		if sim.Grid.IsBarrierAt(c.Loc) {
+			t.Errorf("Creature %d was placed on a wall at %v", c.Id, c.Loc)
+		}
+	}
+
+	// A creature facing the barrier sees it as a wall
+	sim.Grid.Set(grid.Coord{X: 27, Y: 7}, grid.EMPTY)
+	sim.Grid.Set(grid.Coord{X: 28, Y: 7}, grid.EMPTY)
+	sim.Grid.Set(grid.Coord{X: 29, Y: 7}, grid.EMPTY)
+	c := &Creature{Loc: grid.Coord{X: 27, Y: 7}, LastMoveDir: grid.E, Genome: &Genome{SightDistance: 4}}
+	if got := calculateSightPopFwd(c, sim.Grid, sim.Populations[0], 0); got != 0.5 {
+		t.Errorf("SIGHT_POPULATION_FORWARD facing the barrier = %f, want 0.5", got)
+	}
+
+	for sim.Tick < 5 {
+		sim.Step()
+	}
+	// It moves a cell a tick, unless it has had to wait for a creature
+	cells := sim.Grid.BarrierCells()
+	if len(cells) != 30 || cells[0].Y < 5 || cells[0].Y > 10 {
+		t.Errorf("Barrier should have moved down up to 5 cells, it's at %v", cells)
+	}
+	for _, c := range sim.Creatures() {
+		if c.Alive && sim.Grid.IsBarrierAt(c.Loc) {
+			t.Errorf("Creature %d is inside a wall at %v", c.Id, c.Loc)
+		}
+	}
+}
+
+func TestBarrier_Sensors(t *testing.T) {
+	defer smallWorld()()
+	Params.GridWidth, Params.GridHeight = 30, 30
+	g := grid.NewGrid(30, 30, int(grid.NO_WALLS))
+	g.DrawBox(10, 0, 12, 30)
+	c := &Creature{Loc: grid.Coord{X: 5, Y: 5}, LastMoveDir: grid.E, Genome: &Genome{SightDistance: 8}}
+
+	if got := senseBarrierForward(c, g, nil, 0); got != 0.5 {
+		t.Errorf("BARRIER_FORWARD with 4 free cells ahead = %f, want 0.5", got)
+	}
+	if got := senseBarrierDist(c, g, nil, 0); got != 5.0/14 {
+		t.Errorf("BARRIER_DIST 5 cells from the wall = %f, want %f", got, 5.0/14)
+	}
+	c.LastMoveDir = grid.N
+	lr := senseBarrierLR(c, g, nil, 0)
+	c.LastMoveDir = grid.S
+	if lr == 0.5 || lr+senseBarrierLR(c, g, nil, 0) != 1 {
+		t.Errorf("BARRIER_LR should be mirrored when facing the other way, got %f", lr)
+	}
+	c.LastMoveDir = grid.W
+	if got := senseBarrierForward(c, g, nil, 0); got != 1 {
+		t.Errorf("BARRIER_FORWARD facing away from the wall = %f, want 1", got)
+	}
+
+	// Creatures don't hide the wall, and the distance field follows the walls as they change
+	c.LastMoveDir = grid.E
+	g.Set(grid.Coord{X: 7, Y: 5}, grid.RESERVED_CELL_TYPES)
+	if got := senseBarrierForward(c, g, nil, 0); got != 0.5 {
+		t.Errorf("BARRIER_FORWARD past a creature = %f, want 0.5", got)
+	}
+	g.Set(grid.Coord{X: 5, Y: 7}, grid.WALL)
+	if got := g.BarrierDistance().At(c.Loc); got != 2 {
+		t.Errorf("Barrier distance after adding a nearer wall = %d, want 2", got)
+	}
+	g.ZeroFill()
+	if got := senseBarrierDist(c, g, nil, 0); got != 1 {
+		t.Errorf("BARRIER_DIST with no walls = %f, want 1", got)
+	}
+}
+
+func TestBarrier_KeepsUnaffectedFields(t *testing.T) {
+	g := grid.NewGrid(30, 30, int(grid.NO_WALLS))
+	post := &grid.Barrier{Name: "post", Path: []grid.Coord{{X: 5, Y: 5}}}
+	// Behind the wall, where the path field below can't reach
+	mover := &grid.Barrier{Name: "mover", Path: []grid.Coord{{X: 25, Y: 10}, {X: 25, Y: 20}}}
+	if err := g.ApplyMap(&grid.MapFile{Type: "NO_WALLS", Walls: []grid.Box{{MinX: 20, MinY: 0, MaxX: 21, MaxY: 30}}, Barriers: []*grid.Barrier{post, mover}}); err != nil {
+		t.Fatal(err)
+	}
+	sources := func() []grid.Coord { return []grid.Coord{{X: 1, Y: 1}} }
+	path := g.PathDistance("left", sources)
+	barriers := g.BarrierDistance()
+
+	g.UpdateBarriers(1)
+	if mover.Cells()[0].Y != 11 {
+		t.Fatalf("Mover at %v, want y=11", mover.Cells())
+	}
+	if g.PathDistance("left", sources) != path {
+		t.Error("A barrier moving out of reach shouldn't rebuild the path field")
+	}
+	if g.BarrierDistance() == barriers {
+		t.Error("A barrier moving should rebuild the barrier distances")
+	}
+
+	g.Set(grid.Coord{X: 10, Y: 10}, grid.WALL)
+	if g.PathDistance("left", sources) == path {
+		t.Error("A wall in reach should rebuild the path field")
+	}
+	if got := g.PathDistance("left", sources).At(grid.Coord{X: 10, Y: 10}); got != grid.Unreachable {
+		t.Errorf("Distance into the new wall = %d, want Unreachable", got)
+	}
+}
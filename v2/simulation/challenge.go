@@ -2,7 +2,10 @@ package simulation
 
 import (
 	"biogo/v2/grid"
-	"math"
+	"biogo/v2/utils"
+	"fmt"
+	"strconv"
+	"strings"
 )
 
 type ChallengeType int
@@ -17,9 +20,90 @@ const (
 	MiddleWall
 )
 
-func PassedSurvivalCriteria(c *Creature, s *Simulation) bool {
+var challengeNames = []string{"LeftSurvive", "RightSurvive", "FarLeftSurvive", "Groups", "Center", "AllSurvive", "MiddleWall"}
 
-	switch s.Challenge {
+func (c ChallengeType) String() string {
+	if int(c) >= 0 && int(c) < len(challengeNames) {
+		return challengeNames[c]
+	}
+	return fmt.Sprintf("ChallengeType(%d)", int(c))
+}
+
+// ParseChallenge reads a challenge by name (case insensitive) or number.
+func ParseChallenge(s string) (ChallengeType, error) {
+	for i, name := range challengeNames {
+		if strings.EqualFold(s, name) {
+			return ChallengeType(i), nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(challengeNames) {
+		return ChallengeType(n), nil
+	}
+	return 0, fmt.Errorf("unknown challenge %q", s)
+}
+
+// centerRadius is the Center challenge's zone radius.
+const centerRadius = 50
+
+// InZone reports whether loc is in the area a challenge asks creatures to reach. Only challenges
+// decided by location have a zone.
+func InZone(challenge ChallengeType, g *grid.Grid, loc grid.Coord) bool {
+	switch challenge {
+	case LeftSurvive:
+		return loc.X < g.SizeX()/2
+	case FarLeftSurvive:
+		return loc.X < g.SizeX()/10
+	case RightSurvive:
+		return loc.X > g.SizeX()/2
+	case Center:
+		dx, dy := loc.X-g.SizeX()/2, loc.Y-g.SizeY()/2
+		// Within centerRadius when rounded down
+		return dx*dx+dy*dy < (centerRadius+1)*(centerRadius+1)
+	}
+	return false
+}
+
+// HasZone reports whether a challenge has a zone for InZone to test.
+func HasZone(challenge ChallengeType) bool {
+	switch challenge {
+	case LeftSurvive, FarLeftSurvive, RightSurvive, Center:
+		return true
+	}
+	return false
+}
+
+// zoneDistance returns the path distance around walls from every cell to the challenge's zone, or
+// nil for a challenge without one.
+func zoneDistance(challenge ChallengeType, g *grid.Grid) *grid.DistanceField {
+	if !HasZone(challenge) {
+		return nil
+	}
+	return g.PathDistance(challenge.String(), func() []grid.Coord {
+		return challenge.Zone(g)
+	})
+}
+
+// Zone lists the cells of the challenge's zone, for drawing it or measuring distances to it. It's
+// empty for a challenge without one.
+func (challenge ChallengeType) Zone(g *grid.Grid) []grid.Coord {
+	cells := []grid.Coord{}
+	if !HasZone(challenge) {
+		return cells
+	}
+	for x := 0; x < g.SizeX(); x++ {
+		for y := 0; y < g.SizeY(); y++ {
+			if loc := (grid.Coord{X: x, Y: y}); InZone(challenge, g, loc) {
+				cells = append(cells, loc)
+			}
+		}
+	}
+	return cells
+}
+
+// PassedSurvivalCriteria reports whether a creature passed the challenge its population faces.
+func PassedSurvivalCriteria(c *Creature, s *Simulation, challenge ChallengeType) bool {
+
+	switch challenge {
 	case LeftSurvive:
 		if c.Loc.X < int(Params.GridWidth/2) {
 			return true
@@ -57,15 +141,7 @@ func PassedSurvivalCriteria(c *Creature, s *Simulation) bool {
 			return true
 		}
 	case Center:
-		center := grid.Coord{X: int(s.Grid.SizeX() / 2), Y: int(s.Grid.SizeY() / 2)}
-		radius := 50
-		offset := grid.Coord{
-			X: int(math.Abs(float64(c.Loc.X - center.X))),
-			Y: int(math.Abs(float64(c.Loc.Y - center.Y))),
-		}
-		dist := math.Sqrt(float64(offset.X*offset.X) + float64(offset.Y*offset.Y))
-		// fmt.Printf("dist: %f, center: %v, loc: %v\n", dist, center, c.Loc)
-		return int(dist) <= radius
+		return InZone(Center, s.Grid, c.Loc)
 	case AllSurvive:
 		fallthrough
 	default:
@@ -73,3 +149,41 @@ func PassedSurvivalCriteria(c *Creature, s *Simulation) bool {
 	}
 	return false
 }
+
+// creatureZone returns the zone distance field for the creature's own challenge.
+func creatureZone(c *Creature, g *grid.Grid) *grid.DistanceField {
+	challenge := Params.Challenge
+	if c.Population != nil {
+		challenge = c.Population.Config.Challenge
+	}
+	return zoneDistance(challenge, g)
+}
+
+// senseGoalDist is the path distance to the creature's challenge zone, scaled like BOUNDARY_DIST;
+// 0 in the zone, and 1 when there's no zone or no way there.
+func senseGoalDist(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	field := creatureZone(c, g)
+	if field == nil || field.At(c.Loc) == grid.Unreachable {
+		return 1
+	}
+	maxPossible := utils.Max(Params.GridWidth/2-1, Params.GridHeight/2-1)
+	return float32(field.At(c.Loc)) / float32(maxPossible)
+}
+
+// Goal gradient sensors are 0.5 when a step doesn't change the path distance to the zone, above when
+// a step ahead (or to the right) gets nearer.
+func senseGoalForward(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	field := creatureZone(c, g)
+	if field == nil {
+		return 0.5
+	}
+	return (field.Gradient(c.Loc, c.LastMoveDir) + 1) / 2
+}
+
+func senseGoalLR(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	field := creatureZone(c, g)
+	if field == nil {
+		return 0.5
+	}
+	return (field.Gradient(c.Loc, c.LastMoveDir.Rotate90CW()) + 1) / 2
+}
@@ -0,0 +1,96 @@
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"math"
+	"testing"
+)
+
+func TestPathDistance_GoesAroundWalls(t *testing.T) {
+	g := grid.NewGrid(30, 30, int(grid.NO_WALLS))
+	g.DrawBox(15, 0, 16, 25)
+	goal := grid.Coord{X: 20, Y: 5}
+	built := 0
+	field := func() *grid.DistanceField {
+		return g.PathDistance("goal", func() []grid.Coord {
+			built++
+			return []grid.Coord{goal}
+		})
+	}
+
+	start := grid.Coord{X: 10, Y: 5}
+	d := field().At(start)
+	if d <= 10 {
+		t.Fatalf("Path distance through the wall = %d, want it to go round", d)
+	}
+	if field().At(grid.Coord{X: 15, Y: 5}) != grid.Unreachable {
+		t.Error("Wall cells should be unreachable")
+	}
+	// Following the flow field takes exactly the path distance
+	loc := start
+	for steps := 0; loc != goal; steps++ {
+		dir := field().Downhill(loc)
+		if dir == grid.CENTER || steps > d {
+			t.Fatalf("Flow field stalled at %v after %d steps", loc, steps)
+		}
+		loc = grid.Coord{X: loc.X + dir.X, Y: loc.Y + dir.Y}
+		if steps+1 == d && loc != goal {
+			t.Fatalf("Flow field took more than %d steps", d)
+		}
+	}
+	if built != 1 {
+		t.Errorf("Field built %d times, want it cached", built)
+	}
+
+	// Opening a gap in the wall rebuilds the field
+	g.Set(grid.Coord{X: 15, Y: 5}, grid.EMPTY)
+	if d := field().At(start); d != 10 || built != 2 {
+		t.Errorf("Path distance through the gap = %d (built %d times), want 10 (2)", d, built)
+	}
+}
+
+func TestChallenge_CenterZone(t *testing.T) {
+	s := &Simulation{Grid: grid.NewGrid(160, 140, 0)}
+	center := grid.Coord{X: 80, Y: 70}
+	for x := 0; x < 160; x++ {
+		for y := 0; y < 140; y++ {
+			c := &Creature{Loc: grid.Coord{X: x, Y: y}}
+			dist := math.Sqrt(float64((x-center.X)*(x-center.X) + (y-center.Y)*(y-center.Y)))
+			if got, want := PassedSurvivalCriteria(c, s, Center), int(dist) <= 50; got != want {
+				t.Fatalf("Center at %v = %v, want %v", c.Loc, got, want)
+			}
+		}
+	}
+}
+
+func TestSenseGoal(t *testing.T) {
+	defer smallWorld()()
+	Params.GridWidth, Params.GridHeight = 100, 40
+	g := grid.NewGrid(100, 40, int(grid.NO_WALLS))
+	pop := &Population{Config: PopulationConfig{Challenge: FarLeftSurvive}}
+	c := &Creature{Loc: grid.Coord{X: 30, Y: 20}, LastMoveDir: grid.W, Population: pop}
+
+	if got := senseGoalForward(c, g, pop, 0); got != 1 {
+		t.Errorf("GOAL_FORWARD facing the zone = %f, want 1", got)
+	}
+	c.LastMoveDir = grid.E
+	if got := senseGoalForward(c, g, pop, 0); got != 0 {
+		t.Errorf("GOAL_FORWARD facing away = %f, want 0", got)
+	}
+	c.LastMoveDir = grid.N
+	if got := senseGoalLR(c, g, pop, 0); got == 0.5 {
+		t.Error("GOAL_LR facing along the zone's edge should point to one side")
+	}
+	if got := senseGoalDist(c, g, pop, 0); got != 21.0/49 {
+		t.Errorf("GOAL_DIST 21 cells from the zone = %f, want %f", got, 21.0/49)
+	}
+	c.Loc.X = 5
+	if got := senseGoalDist(c, g, pop, 0); got != 0 {
+		t.Errorf("GOAL_DIST in the zone = %f, want 0", got)
+	}
+
+	pop.Config.Challenge = AllSurvive
+	if senseGoalDist(c, g, pop, 0) != 1 || senseGoalForward(c, g, pop, 0) != 0.5 {
+		t.Error("Goal sensors should be neutral for a challenge without a zone")
+	}
+}
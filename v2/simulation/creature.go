@@ -21,9 +21,12 @@ type Creature struct {
 	BirthLoc       grid.Coord
 	LastMoveDir    grid.Dir
 	Genome         *Genome
+	Population     *Population
 
 	actionLevelsBuf       []float32
 	neuronAccumulatorsBuf []float32
+	sensorValuesBuf       []float32 // Indexed by sensor ID, only the sensors in Nnet.Sensors are filled in
+	edgeInputsBuf         []float32 // The value each edge carried in the last FeedForward
 }
 
 func NewCreature(id int, loc grid.Coord, g *Genome) *Creature {
@@ -46,9 +49,15 @@ func NewCreature(id int, loc grid.Coord, g *Genome) *Creature {
 // Takes a creature's genome and uses it to build a NeuralNetwork
 func (c *Creature) CreateNeuralNet() {
 	c.Nnet = *CreateNeuralNetworkFromGenome(c.Genome.Brain, c.Genome.NeuronCount)
+	c.Nnet.Activation = c.Genome.ActivationFunction()
+	if Params.Plasticity {
+		c.Nnet.LearningRate = Params.BaseLearningRate * float32(c.Genome.LearningRate) / math.MaxUint8
+	}
 	// Preallocate buffers for FeedForward
-	c.actionLevelsBuf = make([]float32, ACTION_COUNT)
-	c.neuronAccumulatorsBuf = make([]float32, len(c.Nnet.HiddenNeurons))
+	c.actionLevelsBuf = make([]float32, ActionCount())
+	c.neuronAccumulatorsBuf = make([]float32, len(c.Nnet.Neurons))
+	c.sensorValuesBuf = make([]float32, SensorCount())
+	c.edgeInputsBuf = make([]float32, len(c.Nnet.Weights))
 }
 
 func (c Creature) String() string {
@@ -0,0 +1,128 @@
+// export.go: Exports a creature's neural net as a graph of named nodes and weighted edges, in Graphviz DOT or JSON form.
+
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	NODE_SENSOR = "sensor"
+	NODE_NEURON = "neuron"
+	NODE_ACTION = "action"
+)
+
+// NetGraph is a neural net as named nodes and weighted edges. It marshals to JSON as is.
+type NetGraph struct {
+	Nodes []NetNode `json:"nodes"`
+	Edges []NetEdge `json:"edges"`
+}
+
+type NetNode struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"` // NODE_SENSOR, NODE_NEURON or NODE_ACTION
+	Name string `json:"name"`
+	Code string `json:"code,omitempty"`
+}
+
+type NetEdge struct {
+	Source string  `json:"source"`
+	Target string  `json:"target"`
+	Weight float32 `json:"weight"`
+}
+
+func graphNode(nodeType, id byte, source bool) NetNode {
+	switch {
+	case nodeType == NEURON:
+		name := fmt.Sprintf("N%d", id)
+		return NetNode{ID: name, Kind: NODE_NEURON, Name: name}
+	case source:
+		node := NetNode{ID: "S_" + SensorName(id), Kind: NODE_SENSOR, Name: SensorName(id)}
+		if int(id) < len(sensors) {
+			node.Code = sensors[id].Code
+		}
+		return node
+	default:
+		node := NetNode{ID: "A_" + ActionName(id), Kind: NODE_ACTION, Name: ActionName(id)}
+		if int(id) < len(actions) {
+			node.Code = actions[id].Code
+		}
+		return node
+	}
+}
+
+// Graph converts the net's edges into named nodes. Nodes are sorted sensors first, then neurons, then actions.
+func (n NeuralNet) Graph() NetGraph {
+	graph := NetGraph{Nodes: []NetNode{}, Edges: []NetEdge{}}
+	seen := map[string]bool{}
+	add := func(node NetNode) {
+		if !seen[node.ID] {
+			seen[node.ID] = true
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+	for i, gene := range n.Edges {
+		source := graphNode(gene.SourceType, gene.SourceID, true)
+		sink := graphNode(gene.SinkType, gene.SinkID, false)
+		add(source)
+		add(sink)
+		// A compiled net's Weights include what the creature has learned since birth
+		weight := gene.WeightAsFloat32()
+		if i < len(n.Weights) {
+			weight = n.Weights[i]
+		}
+		graph.Edges = append(graph.Edges, NetEdge{Source: source.ID, Target: sink.ID, Weight: weight})
+	}
+
+	kindOrder := map[string]int{NODE_SENSOR: 0, NODE_NEURON: 1, NODE_ACTION: 2}
+	sort.SliceStable(graph.Nodes, func(i, j int) bool {
+		a, b := graph.Nodes[i], graph.Nodes[j]
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		return a.ID < b.ID
+	})
+	return graph
+}
+
+// DOT renders the net for Graphviz, with sensors on the left and actions on the right.
+// Positive weights are drawn green, negative red, and thicker the stronger they are.
+func (n NeuralNet) DOT(title string) string {
+	graph := n.Graph()
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", title)
+	b.WriteString("  rankdir=LR;\n")
+	shapes := map[string]string{NODE_SENSOR: "box", NODE_NEURON: "circle", NODE_ACTION: "doubleoctagon"}
+	ranks := map[string]string{NODE_SENSOR: "source", NODE_ACTION: "sink"}
+	for _, kind := range []string{NODE_SENSOR, NODE_NEURON, NODE_ACTION} {
+		ids := []string{}
+		for _, node := range graph.Nodes {
+			if node.Kind == kind {
+				fmt.Fprintf(&b, "  %q [label=%q, shape=%s];\n", node.ID, node.Name, shapes[kind])
+				ids = append(ids, fmt.Sprintf("%q", node.ID))
+			}
+		}
+		if rank, ok := ranks[kind]; ok && len(ids) > 0 {
+			fmt.Fprintf(&b, "  { rank=%s; %s; }\n", rank, strings.Join(ids, "; "))
+		}
+	}
+	for _, edge := range graph.Edges {
+		color := "darkgreen"
+		if edge.Weight < 0 {
+			color = "red"
+		}
+		width := 0.5 + 2.5*float64(abs32(edge.Weight))
+		fmt.Fprintf(&b, "  %q -> %q [label=\"%+.2f\", color=%s, penwidth=%.2f];\n", edge.Source, edge.Target, edge.Weight, color, width)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func abs32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,71 @@
+package simulation
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNeuralNetExport(t *testing.T) {
+	genes := []*Gene{
+		{SourceType: SENSOR, SourceID: LOC_X, SinkType: NEURON, SinkID: 0, Weight: 255},
+		{SourceType: NEURON, SourceID: 0, SinkType: ACTION, SinkID: MOVE_WEST, Weight: 0},
+	}
+	nnet := CreateNeuralNetworkFromGenome(genes, 1)
+
+	dot := nnet.DOT("test")
+	for _, want := range []string{`"S_LOC_X" -> "N0"`, `"N0" -> "A_MOVE_WEST"`, `label="+1.00"`, `label="-1.00"`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output is missing %s:\n%s", want, dot)
+		}
+	}
+
+	data, err := json.Marshal(nnet.Graph())
+	if err != nil {
+		t.Fatalf("Marshalling graph returned error: %v", err)
+	}
+	graph := NetGraph{}
+	if err := json.Unmarshal(data, &graph); err != nil {
+		t.Fatalf("Unmarshalling graph returned error: %v", err)
+	}
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 2 {
+		t.Fatalf("Expected 3 nodes and 2 edges, got %d and %d", len(graph.Nodes), len(graph.Edges))
+	}
+	if graph.Nodes[0].Kind != NODE_SENSOR || graph.Nodes[1].Kind != NODE_NEURON || graph.Nodes[2].Kind != NODE_ACTION {
+		t.Errorf("Nodes should be ordered sensor, neuron, action, got %v", graph.Nodes)
+	}
+}
+
+func TestCreature_Brain(t *testing.T) {
+	defer smallWorld()()
+	sim := New()
+	sim.Step()
+	for _, c := range sim.Creatures() {
+		if len(c.Nnet.Weights) == 0 {
+			continue
+		}
+		before := c.Brain()
+		brain := c.Brain()
+		if len(brain.Nodes) != len(c.Nnet.Graph().Nodes) || len(brain.Edges) != len(c.Nnet.Weights) {
+			t.Fatalf("Brain has %d nodes and %d edges, want the net's %d and %d", len(brain.Nodes), len(brain.Edges), len(c.Nnet.Graph().Nodes), len(c.Nnet.Weights))
+		}
+		for i, node := range brain.Nodes {
+			if node != before.Nodes[i] {
+				t.Fatalf("Reading the brain changed node %s from %v to %v", node.ID, before.Nodes[i].Value, node.Value)
+			}
+		}
+		// Each action's level is the sum of the signals into it
+		sums := make([]float32, len(brain.Nodes))
+		for _, e := range brain.Edges {
+			sums[e.Target] += e.Signal
+		}
+		for i, node := range brain.Nodes {
+			if node.Kind == NODE_ACTION && math.Abs(float64(node.Value-sums[i])) > 1e-5 {
+				t.Errorf("Action %s level %f, want the sum of its signals %f", node.Name, node.Value, sums[i])
+			}
+		}
+		return
+	}
+	t.Skip("No creature with a brain")
+}
@@ -4,12 +4,17 @@ package simulation
 
 import (
 	"biogo/v2/grid"
-	"math"
 )
 
 // The "Brains"
 
+// FeedForward runs the compiled net once. Each sensor the net uses is read once up front, however
+// many edges it feeds. The hidden neurons then update, either all at once from the previous step's
+// outputs (EvaluationBatched) or one at a time in dependency order (EvaluationTopological), and
+// finally the edges into actions are summed. Under RecurrenceStateless "the previous step's output"
+// is always the initial output.
 func (c *Creature) FeedForward(g *grid.Grid, p *Population, step int) []float32 {
+	n := &c.Nnet
 	// Zero buffers
 	for i := range c.actionLevelsBuf {
 		c.actionLevelsBuf[i] = 0
@@ -17,32 +22,53 @@ func (c *Creature) FeedForward(g *grid.Grid, p *Population, step int) []float32
 	for i := range c.neuronAccumulatorsBuf {
 		c.neuronAccumulatorsBuf[i] = 0
 	}
-	neuronOutputsEvaluated := false
-
-	for _, gene := range c.Nnet.Edges {
+	if Params.Recurrence == RecurrenceStateless {
+		for i := range n.Neurons {
+			n.Neurons[i].Output = CreateInitialNeuronOutput()
+		}
+	}
+	for _, id := range n.Sensors {
+		c.sensorValuesBuf[id] = c.GetSensor(id, g, p, step)
+	}
 
-		// First we evaluate the outputs to ACTIONS
-		if gene.SinkType == ACTION && !neuronOutputsEvaluated {
-			for key, neuron := range c.Nnet.HiddenNeurons {
-				if neuron.Driven {
-					neuron.Output = float32(math.Tanh(float64(c.neuronAccumulatorsBuf[int(key)])))
-				}
+	if Params.Evaluation == EvaluationTopological {
+		start := 0
+		for k, id := range n.NeuronOrder {
+			end := n.NeuronEdgeEnds[k]
+			for i := start; i < end; i++ {
+				c.edgeInputsBuf[i] = c.edgeInput(i)
+				c.neuronAccumulatorsBuf[id] += c.edgeInputsBuf[i] * n.Weights[i]
+			}
+			start = end
+			if n.Neurons[id].Driven {
+				n.Neurons[id].Output = n.Activation.Activate(c.neuronAccumulatorsBuf[id])
 			}
-			neuronOutputsEvaluated = true
 		}
-
-		var inputVal float32
-		if gene.SourceType == SENSOR {
-			inputVal = c.GetSensor(gene.SourceID, g, p, step)
-		} else {
-			inputVal = c.Nnet.HiddenNeurons[gene.SourceID].Output
+	} else {
+		for i := 0; i < n.NeuronEdgeCount; i++ {
+			c.edgeInputsBuf[i] = c.edgeInput(i)
+			c.neuronAccumulatorsBuf[n.SinkIDs[i]] += c.edgeInputsBuf[i] * n.Weights[i]
 		}
-
-		if gene.SinkType == ACTION {
-			c.actionLevelsBuf[gene.SinkID] += inputVal * gene.WeightAsFloat32()
-		} else {
-			c.neuronAccumulatorsBuf[gene.SinkID] += inputVal * gene.WeightAsFloat32()
+		for i := range n.Neurons {
+			if n.Neurons[i].Driven {
+				n.Neurons[i].Output = n.Activation.Activate(c.neuronAccumulatorsBuf[i])
+			}
 		}
 	}
+	for i := n.NeuronEdgeCount; i < len(n.Weights); i++ {
+		c.edgeInputsBuf[i] = c.edgeInput(i)
+		c.actionLevelsBuf[n.SinkIDs[i]] += c.edgeInputsBuf[i] * n.Weights[i]
+	}
+	if n.LearningRate != 0 {
+		c.learn()
+	}
 	return c.actionLevelsBuf
 }
+
+// edgeInput is the value flowing into compiled edge i, from either this step's sensor reading or a neuron.
+func (c *Creature) edgeInput(i int) float32 {
+	if c.Nnet.SourceTypes[i] == SENSOR {
+		return c.sensorValuesBuf[c.Nnet.SourceIDs[i]]
+	}
+	return c.Nnet.Neurons[c.Nnet.SourceIDs[i]].Output
+}
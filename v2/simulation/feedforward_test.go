@@ -1,14 +1,121 @@
 package simulation
 
 import (
+	"biogo/v2/grid"
+	"math"
 	"testing"
 )
 
+// referenceNet is the map based FeedForward that the compiled net replaced. It's kept here to check
+// the compiled net against and to benchmark the difference.
+type referenceNet struct {
+	edges   []*Gene
+	neurons map[byte]*Neuron
+	actions []float32
+	accum   []float32
+}
+
+func newReferenceNet(n NeuralNet) *referenceNet {
+	r := &referenceNet{
+		edges:   n.Edges,
+		neurons: make(map[byte]*Neuron, len(n.Neurons)),
+		actions: make([]float32, ActionCount()),
+		accum:   make([]float32, len(n.Neurons)),
+	}
+	for i, neuron := range n.Neurons {
+		copied := neuron
+		r.neurons[byte(i)] = &copied
+	}
+	return r
+}
+
+func (r *referenceNet) feedForward(c *Creature, g *grid.Grid, p *Population, step int) []float32 {
+	for i := range r.actions {
+		r.actions[i] = 0
+	}
+	for i := range r.accum {
+		r.accum[i] = 0
+	}
+	neuronOutputsEvaluated := false
+
+	for _, gene := range r.edges {
+		if gene.SinkType == ACTION && !neuronOutputsEvaluated {
+			for key, neuron := range r.neurons {
+				if neuron.Driven {
+					neuron.Output = float32(math.Tanh(float64(r.accum[int(key)])))
+				}
+			}
+			neuronOutputsEvaluated = true
+		}
+
+		var inputVal float32
+		if gene.SourceType == SENSOR {
+			inputVal = c.GetSensor(gene.SourceID, g, p, step)
+		} else {
+			inputVal = r.neurons[gene.SourceID].Output
+		}
+
+		if gene.SinkType == ACTION {
+			r.actions[gene.SinkID] += inputVal * gene.WeightAsFloat32()
+		} else {
+			r.accum[gene.SinkID] += inputVal * gene.WeightAsFloat32()
+		}
+	}
+	return r.actions
+}
+
+func TestFeedForward_MatchesReference(t *testing.T) {
+	defer withSensors(map[string]bool{"RANDOM": false})()
+	sim := New()
+	for _, c := range sim.Populations[0].Creatures[:100] {
+		ref := newReferenceNet(c.Nnet)
+		for step := 0; step < 3; step++ {
+			want := append([]float32{}, ref.feedForward(c, sim.Grid, sim.Populations[0], step)...)
+			got := c.FeedForward(sim.Grid, sim.Populations[0], step)
+			for i := range want {
+				if math.Abs(float64(got[i]-want[i])) > 1e-5 {
+					t.Fatalf("Creature %d step %d action %s = %f, reference gives %f", c.Id, step, ActionName(byte(i)), got[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+var benchSims = map[bool]*Simulation{}
+
+// benchSim returns a simulation shared between benchmarks, so compiled and reference runs see the same creatures.
+// cheapSensors disables the density and genetic similarity sensors, which would otherwise dominate the time taken.
+func benchSim(cheapSensors bool) *Simulation {
+	if sim, ok := benchSims[cheapSensors]; ok {
+		return sim
+	}
+	overrides := map[string]bool{}
+	for _, id := range []byte{POPULATION_FORWARD, POPULATION_LR, POPULATION_LOCAL_DENSITY, GENETIC_SIM_FORWARD} {
+		overrides[SensorName(id)] = !cheapSensors
+	}
+	restore := withSensors(overrides)
+	sim := New()
+	restore()
+	benchSims[cheapSensors] = sim
+	return sim
+}
+
+// benchCreature is the creature with the largest brain.
+func benchCreature(sim *Simulation) *Creature {
+	best := sim.Populations[0].Creatures[0]
+	for _, c := range sim.Populations[0].Creatures {
+		if len(c.Nnet.Edges) > len(best.Nnet.Edges) {
+			best = c
+		}
+	}
+	return best
+}
+
 func BenchmarkFeedForward(b *testing.B) {
 	sim := New()
-	c := sim.Population.Creatures[0]
+	c := sim.Populations[0].Creatures[0]
 	grid := sim.Grid
-	pop := sim.Population
+	pop := sim.Populations[0]
 	tick := sim.Tick
 
 	b.ResetTimer()
@@ -16,3 +123,227 @@ func BenchmarkFeedForward(b *testing.B) {
 		c.FeedForward(grid, pop, tick)
 	}
 }
+
+// BenchmarkFeedForwardLargestBrain runs the creature BenchmarkFeedForwardReference does.
+func BenchmarkFeedForwardLargestBrain(b *testing.B) {
+	sim := benchSim(false)
+	c := benchCreature(sim)
+	grid := sim.Grid
+	pop := sim.Populations[0]
+	tick := sim.Tick
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.FeedForward(grid, pop, tick)
+	}
+}
+
+func BenchmarkFeedForwardReference(b *testing.B) {
+	sim := benchSim(false)
+	c := benchCreature(sim)
+	ref := newReferenceNet(c.Nnet)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ref.feedForward(c, sim.Grid, sim.Populations[0], sim.Tick)
+	}
+}
+
+func BenchmarkFeedForwardPopulation(b *testing.B) {
+	sim := benchSim(true)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, c := range sim.Populations[0].Creatures {
+			c.FeedForward(sim.Grid, sim.Populations[0], sim.Tick)
+		}
+	}
+}
+
+func BenchmarkFeedForwardPopulationReference(b *testing.B) {
+	sim := benchSim(true)
+	refs := make([]*referenceNet, len(sim.Populations[0].Creatures))
+	for i, c := range sim.Populations[0].Creatures {
+		refs[i] = newReferenceNet(c.Nnet)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, c := range sim.Populations[0].Creatures {
+			refs[j].feedForward(c, sim.Grid, sim.Populations[0], sim.Tick)
+		}
+	}
+}
+
+func TestFeedForward_ReadsEachUsedSensorOnce(t *testing.T) {
+	wiredCount, unwiredCount := 0, 0
+	wired := RegisterSensor(Sensor{Name: "WIRED", Enabled: true, Eval: func(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+		wiredCount++
+		return 1
+	}})
+	RegisterSensor(Sensor{Name: "UNWIRED", Enabled: true, Eval: func(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+		unwiredCount++
+		return 1
+	}})
+	defer func() { sensors = sensors[:len(sensors)-2] }()
+
+	// Genes pick from the enabled sensors, so find the gene ID that maps onto WIRED.
+	geneID := byte(0)
+	for i, id := range enabledSensorIDs() {
+		if id == wired {
+			geneID = byte(i)
+		}
+	}
+	g := &Genome{NeuronCount: 0, BrainLength: 5}
+	for _, action := range []byte{MOVE_X, MOVE_Y, MOVE_EAST, MOVE_WEST, MOVE_NORTH} {
+		g.Brain = append(g.Brain, &Gene{SourceType: SENSOR, SourceID: geneID, SinkType: ACTION, SinkID: action, Weight: 255})
+	}
+	c := &Creature{Genome: g}
+	c.CreateNeuralNet()
+	if len(c.Nnet.Sensors) != 1 || c.Nnet.Sensors[0] != wired {
+		t.Fatalf("Expected the net to use only the WIRED sensor, got %v", c.Nnet.Sensors)
+	}
+
+	c.FeedForward(nil, nil, 0)
+	if wiredCount != 1 {
+		t.Errorf("Sensor wired to 5 edges was read %d times in one step, want 1", wiredCount)
+	}
+	if unwiredCount != 0 {
+		t.Errorf("Sensor not in the net was read %d times, want 0", unwiredCount)
+	}
+}
+
+func TestActivationType_Activate(t *testing.T) {
+	cases := []struct {
+		a    ActivationType
+		x    float32
+		want float32
+	}{
+		{ActivationTanh, 0, 0},
+		{ActivationReLU, -2, 0},
+		{ActivationReLU, 2, 2},
+		{ActivationSigmoid, 0, 0.5},
+		{ActivationStep, -0.1, 0},
+		{ActivationStep, 0.1, 1},
+		{ActivationIdentity, -3, -3},
+	}
+	for _, c := range cases {
+		if got := c.a.Activate(c.x); math.Abs(float64(got-c.want)) > 1e-6 {
+			t.Errorf("%s.Activate(%f) = %f, want %f", c.a, c.x, got, c.want)
+		}
+	}
+}
+
+func TestGenome_ActivationFunction(t *testing.T) {
+	defer func(a ActivationType) { Params.Activation = a }(Params.Activation)
+	g := &Genome{Activation: byte(ActivationSigmoid) + byte(ACTIVATION_COUNT)}
+
+	Params.Activation = ActivationStep
+	if got := g.ActivationFunction(); got != ActivationStep {
+		t.Errorf("ActivationFunction() = %s, want the run's %s", got, ActivationStep)
+	}
+	Params.Activation = ActivationFromGenome
+	if got := g.ActivationFunction(); got != ActivationSigmoid {
+		t.Errorf("ActivationFunction() = %s, want the genome's %s", got, ActivationSigmoid)
+	}
+}
+
+// selfLoopCreature has a neuron driven by LOC_X and by itself, feeding MOVE_EAST.
+func selfLoopCreature() *Creature {
+	g := &Genome{NeuronCount: 1, BrainLength: 3, Brain: []*Gene{
+		{SourceType: SENSOR, SourceID: LOC_X, SinkType: NEURON, SinkID: 0, Weight: 255},
+		{SourceType: NEURON, SourceID: 0, SinkType: NEURON, SinkID: 0, Weight: 255},
+		{SourceType: NEURON, SourceID: 0, SinkType: ACTION, SinkID: MOVE_EAST, Weight: 255},
+	}}
+	c := &Creature{Genome: g, Loc: grid.Coord{X: Params.GridWidth / 4}}
+	c.CreateNeuralNet()
+	return c
+}
+
+func TestFeedForward_Recurrence(t *testing.T) {
+	defer func(r RecurrenceMode) { Params.Recurrence = r }(Params.Recurrence)
+
+	Params.Recurrence = RecurrenceStateful
+	c := selfLoopCreature()
+	first := c.FeedForward(nil, nil, 0)[MOVE_EAST]
+	second := c.FeedForward(nil, nil, 1)[MOVE_EAST]
+	if first == second {
+		t.Errorf("With stateful recurrence the self loop should change the output between steps, got %f twice", first)
+	}
+
+	Params.Recurrence = RecurrenceStateless
+	c = selfLoopCreature()
+	first = c.FeedForward(nil, nil, 0)[MOVE_EAST]
+	second = c.FeedForward(nil, nil, 1)[MOVE_EAST]
+	if first != second {
+		t.Errorf("With stateless recurrence identical inputs should give identical outputs, got %f then %f", first, second)
+	}
+}
+
+// chainCreature feeds LOC_X through a chain of neurons into MOVE_EAST. If cycle is set the last
+// neuron also feeds back into the first.
+func chainCreature(length byte, cycle bool) *Creature {
+	g := &Genome{NeuronCount: length, Brain: []*Gene{
+		{SourceType: SENSOR, SourceID: LOC_X, SinkType: NEURON, SinkID: 0, Weight: 255},
+	}}
+	for i := byte(1); i < length; i++ {
+		g.Brain = append(g.Brain, &Gene{SourceType: NEURON, SourceID: i - 1, SinkType: NEURON, SinkID: i, Weight: 255})
+	}
+	if cycle {
+		g.Brain = append(g.Brain, &Gene{SourceType: NEURON, SourceID: length - 1, SinkType: NEURON, SinkID: 0, Weight: 255})
+	}
+	g.Brain = append(g.Brain, &Gene{SourceType: NEURON, SourceID: length - 1, SinkType: ACTION, SinkID: MOVE_EAST, Weight: 255})
+	g.BrainLength = byte(len(g.Brain))
+	c := &Creature{Genome: g, Loc: grid.Coord{X: Params.GridWidth / 4}}
+	c.CreateNeuralNet()
+	return c
+}
+
+func TestFeedForward_TopologicalChain(t *testing.T) {
+	defer func(e EvaluationMode) { Params.Evaluation = e }(Params.Evaluation)
+	const length = 3
+	x := float64(chainCreature(length, false).GetSensor(LOC_X, nil, nil, 0))
+	settled := x
+	for i := 0; i < length; i++ {
+		settled = math.Tanh(settled)
+	}
+
+	Params.Evaluation = EvaluationTopological
+	c := chainCreature(length, false)
+	if delayed := c.Nnet.DelayedEdges(); delayed != 0 {
+		t.Errorf("An acyclic chain should have no delayed edges, got %d", delayed)
+	}
+	if got := c.FeedForward(nil, nil, 0)[MOVE_EAST]; math.Abs(float64(got)-settled) > 1e-5 {
+		t.Errorf("Topological evaluation should settle the chain in one step, got %f, want %f", got, settled)
+	}
+
+	// Batched evaluation moves the input one neuron along the chain per step.
+	Params.Evaluation = EvaluationBatched
+	c = chainCreature(length, false)
+	for step := 0; step < length; step++ {
+		got := c.FeedForward(nil, nil, step)[MOVE_EAST]
+		if settledYet := math.Abs(float64(got)-settled) <= 1e-5; settledYet != (step == length-1) {
+			t.Errorf("Batched evaluation step %d gave %f, the settled value %f should only appear at step %d", step, got, settled, length-1)
+		}
+	}
+}
+
+func TestFeedForward_TopologicalCycle(t *testing.T) {
+	defer func(e EvaluationMode) { Params.Evaluation = e }(Params.Evaluation)
+	Params.Evaluation = EvaluationTopological
+
+	c := chainCreature(3, true)
+	if delayed := c.Nnet.DelayedEdges(); delayed != 1 {
+		t.Errorf("A single cycle should delay exactly one edge, got %d", delayed)
+	}
+	if delayed := selfLoopCreature().Nnet.DelayedEdges(); delayed != 1 {
+		t.Errorf("A self loop should be a delayed edge, got %d", delayed)
+	}
+
+	// The delayed edge reads the previous step, so the output keeps changing as it feeds back.
+	first := c.FeedForward(nil, nil, 0)[MOVE_EAST]
+	second := c.FeedForward(nil, nil, 1)[MOVE_EAST]
+	if first == second {
+		t.Errorf("The cycle should change the output between steps, got %f twice", first)
+	}
+}
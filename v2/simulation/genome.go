@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
 const (
@@ -17,6 +21,8 @@ const (
 	REPRODUCTION_TYPE
 	NEURON_COUNT
 	NEUROLOGY_LENGTH
+	ACTIVATION
+	LEARNING_RATE
 	// NEUROLOGY - not counted
 	GENOME_STRUCTURE_COUNT
 )
@@ -38,19 +44,85 @@ type Genome struct {
 	Responsiveness   byte
 	MutationRate     byte
 	ReproductionType byte
+	Activation       byte // Neuron activation function, used when Params.Activation is ActivationFromGenome
+	LearningRate     byte // Scales Params.BaseLearningRate when Params.Plasticity is on
 	NeuronCount      byte // Neurons count as the middle layer in the nnet
 	BrainLength      byte // BrainLength determines the number of connections
 	Brain            []*Gene
+	Lineage          int // The random genome this one descends from, numbered from 1. Not serialized, 0 for a parsed genome
 	// TODO
 	// ReproductionRate <- Determines how many children
 }
 
+// genomeTrait describes one of the fixed width fields at the head of a serialized genome, in order.
+type genomeTrait struct {
+	Name  string
+	Bits  int
+	Field func(g *Genome) *byte
+}
+
+var genomeTraits = []genomeTrait{
+	{"OscPeriod", 8, func(g *Genome) *byte { return &g.OscPeriod }},
+	{"MaxEnergy", 8, func(g *Genome) *byte { return &g.MaxEnergy }},
+	{"SightDistance", 8, func(g *Genome) *byte { return &g.SightDistance }},
+	{"Responsiveness", 8, func(g *Genome) *byte { return &g.Responsiveness }},
+	{"MutationRate", 8, func(g *Genome) *byte { return &g.MutationRate }},
+	{"ReproductionType", 1, func(g *Genome) *byte { return &g.ReproductionType }},
+	{"Activation", 8, func(g *Genome) *byte { return &g.Activation }},
+	{"LearningRate", 8, func(g *Genome) *byte { return &g.LearningRate }},
+	{"NeuronCount", 8, func(g *Genome) *byte { return &g.NeuronCount }},
+	{"BrainLength", 8, func(g *Genome) *byte { return &g.BrainLength }},
+}
+
+// genomeLayouts are the headers ParseGenome reads, newest first. Older genome strings lack the
+// traits added since; the header lengths differ by amounts no whole number of genes makes up, so
+// the length of a genome string tells them apart.
+var genomeLayouts = [][]genomeTrait{
+	genomeTraits,
+	withoutTraits("LearningRate"),
+	withoutTraits("LearningRate", "Activation"),
+	withoutTraits("LearningRate", "Activation", "NeuronCount"),
+}
+
+func withoutTraits(names ...string) []genomeTrait {
+	traits := []genomeTrait{}
+	for _, t := range genomeTraits {
+		if !slices.Contains(names, t.Name) {
+			traits = append(traits, t)
+		}
+	}
+	return traits
+}
+
+func layoutBits(layout []genomeTrait) int {
+	n := 0
+	for _, t := range layout {
+		n += t.Bits
+	}
+	return n
+}
+
+// geneFields describes the fields of a serialized gene, in order.
+var geneFields = []struct {
+	Bits  int
+	Field func(g *Gene) *byte
+}{
+	{1, func(g *Gene) *byte { return &g.SourceType }},
+	{8, func(g *Gene) *byte { return &g.SourceID }},
+	{1, func(g *Gene) *byte { return &g.SinkType }},
+	{8, func(g *Gene) *byte { return &g.SinkID }},
+	{8, func(g *Gene) *byte { return &g.Weight }},
+}
+
 func (g Gene) String() string {
 	return fmt.Sprintf("%b%08b%b%08b%08b", g.SourceType, g.SourceID, g.SinkType, g.SinkID, g.Weight)
 }
 
 func (g Genome) String() string {
-	str := fmt.Sprintf("%08b%08b%08b%08b%08b%b%08b", g.OscPeriod, g.MaxEnergy, g.SightDistance, g.Responsiveness, g.MutationRate, g.ReproductionType, g.BrainLength)
+	str := ""
+	for _, t := range genomeTraits {
+		str += fmt.Sprintf("%0*b", t.Bits, *t.Field(&g))
+	}
 	for _, gene := range g.Brain {
 		str += gene.String()
 	}
@@ -61,13 +133,78 @@ func (g Gene) BinaryString() string {
 	return fmt.Sprintf("|%b|%08b|%b|%08b|%08b", g.SourceType, g.SourceID, g.SinkType, g.SinkID, g.Weight)
 }
 func (g Genome) BinaryString() string {
-	str := fmt.Sprintf("%08b|%08b|%08b|%08b|%08b|%b|%08b", g.OscPeriod, g.MaxEnergy, g.SightDistance, g.Responsiveness, g.MutationRate, g.ReproductionType, g.BrainLength)
+	fields := make([]string, len(genomeTraits))
+	for i, t := range genomeTraits {
+		fields[i] = fmt.Sprintf("%0*b", t.Bits, *t.Field(&g))
+	}
+	str := strings.Join(fields, "|")
 	for _, gene := range g.Brain {
 		str += gene.BinaryString()
 	}
 	return str
 }
 
+// ParseGenome reads a genome from the output of Genome.String or Genome.BinaryString, including
+// those of older versions without every trait. Separators and whitespace are ignored.
+func ParseGenome(s string) (*Genome, error) {
+	bits := strings.Map(func(r rune) rune {
+		switch r {
+		case '|', ' ', '\t', '\r', '\n':
+			return -1
+		}
+		return r
+	}, s)
+	if i := strings.IndexFunc(bits, func(r rune) bool { return r != '0' && r != '1' }); i >= 0 {
+		return nil, fmt.Errorf("genome contains invalid character %q", bits[i])
+	}
+
+	geneBits := 0
+	for _, f := range geneFields {
+		geneBits += f.Bits
+	}
+	traits := genomeLayouts[0]
+	for _, layout := range genomeLayouts {
+		if n := layoutBits(layout); len(bits) >= n && (len(bits)-n)%geneBits == 0 {
+			traits = layout
+			break
+		}
+	}
+	headerBits := layoutBits(traits)
+	if len(bits) < headerBits {
+		return nil, fmt.Errorf("genome is %d bits long, the header alone is %d", len(bits), headerBits)
+	}
+	if (len(bits)-headerBits)%geneBits != 0 {
+		return nil, fmt.Errorf("genome brain is %d bits long, not a multiple of the %d bit gene size", len(bits)-headerBits, geneBits)
+	}
+
+	pos := 0
+	next := func(n int) byte {
+		v, _ := strconv.ParseUint(bits[pos:pos+n], 2, 8)
+		pos += n
+		return byte(v)
+	}
+	// Traits an older genome lacks are left zero, tanh with no learning as it had then, except the
+	// neuron count the original format didn't record
+	g := &Genome{NeuronCount: Params.MaxHiddenLayerCount}
+	for _, t := range traits {
+		*t.Field(g) = next(t.Bits)
+	}
+	for pos < len(bits) {
+		gene := &Gene{}
+		for _, f := range geneFields {
+			*f.Field(gene) = next(f.Bits)
+		}
+		g.Brain = append(g.Brain, gene)
+	}
+	if len(g.Brain) == 0 {
+		return nil, fmt.Errorf("genome has no brain genes")
+	}
+	if int(g.BrainLength) != len(g.Brain) {
+		return nil, fmt.Errorf("genome declares %d brain genes but contains %d", g.BrainLength, len(g.Brain))
+	}
+	return g, nil
+}
+
 // To represent genetic diversity visually, we create a color from the genome
 //
 
@@ -121,6 +258,8 @@ func (g Genome) ToByteArray() []byte {
 	arr = append(arr, g.Responsiveness)
 	arr = append(arr, g.MutationRate)
 	arr = append(arr, g.ReproductionType)
+	arr = append(arr, g.Activation)
+	arr = append(arr, g.LearningRate)
 	arr = append(arr, g.NeuronCount)
 	arr = append(arr, g.BrainLength)
 	for _, n := range g.Brain {
@@ -137,7 +276,7 @@ func (g Gene) PrettyString() string {
 }
 
 func (g Genome) PrettyString() string {
-	str := fmt.Sprintf("|%08b|%08d|%08b|%08b|%08b|%b|%08b", g.OscPeriod, g.MaxEnergy, g.SightDistance, g.Responsiveness, g.MutationRate, g.ReproductionType, g.BrainLength)
+	str := fmt.Sprintf("|%08b|%08d|%08b|%08b|%08b|%b|%08b|%08b|%08b|%08b", g.OscPeriod, g.MaxEnergy, g.SightDistance, g.Responsiveness, g.MutationRate, g.ReproductionType, g.Activation, g.LearningRate, g.NeuronCount, g.BrainLength)
 	for _, gene := range g.Brain {
 		str += gene.PrettyString()
 	}
@@ -153,6 +292,11 @@ func (g Gene) WeightAsFloat32() float32 {
 	return byteAsFloat(g.Weight)
 }
 
+// floatAsByte is the inverse of byteAsFloat, rounding to the nearest byte
+func floatAsByte(val float32) byte {
+	return byte(math.Round(float64(utils.RestrictFloat32(-1, 1, val)+1) / 2 * math.MaxUint8))
+}
+
 // makeRandomByte creates a random byte
 func makeRandomBool() byte {
 	return byte(rand.Uint32() >> 31)
@@ -169,14 +313,20 @@ func MakeRandomGene() *Gene {
 	}
 }
 
+// lineages counts the random genomes made, to number their lineages.
+var lineages atomic.Int64
+
 func MakeRandomGenome() *Genome {
 	g := Genome{
+		Lineage:          int(lineages.Add(1)),
 		OscPeriod:        utils.ClampByte(1, math.MaxUint8, utils.MakeRandomByte()), // Must be clamped above zero
 		MaxEnergy:        utils.ClampByte(Params.MinEnergy, Params.MaxEnergy, utils.MakeRandomByte()),
 		SightDistance:    utils.ClampByte(Params.MinSightDistance, Params.MaxSightDistance, utils.MakeRandomByte()),
 		Responsiveness:   utils.MakeRandomByte(),
 		MutationRate:     utils.MakeRandomByte(),
 		ReproductionType: makeRandomBool(),
+		Activation:       utils.MakeRandomByte(),
+		LearningRate:     utils.MakeRandomByte(),
 		NeuronCount:      utils.ClampByte(Params.MinHiddenLayerCount, Params.MaxHiddenLayerCount, utils.MakeRandomByte()),
 		BrainLength:      utils.ClampByte(Params.MinStartNeuronCount, Params.MaxStartNeuronCount, utils.MakeRandomByte()),
 	}
@@ -207,7 +357,11 @@ func (g *Genome) Copy() *Genome {
 
 // Mutate takes a genome and randomly flips bits in it at the rate of Params.BaseMutationRate * g.MutationRate
 func Mutate(g *Genome) {
-	mutationRate := Params.BaseMutationRate * float32(g.MutationRate)
+	mutate(g, Params.BaseMutationRate)
+}
+
+func mutate(g *Genome, baseRate float32) {
+	mutationRate := baseRate * float32(g.MutationRate)
 
 	// Super hacky fix, will need improving
 	for i := 0; i < GENOME_STRUCTURE_COUNT; i++ {
@@ -223,7 +377,7 @@ func Mutate(g *Genome) {
 			case SIGHT_DISTANCE:
 				new := g.SightDistance
 				new ^= byte(1 << (rand.Uint32() >> 29))
-				g.SightDistance ^= utils.ClampByte(Params.MinSightDistance, Params.MaxSightDistance, new)
+				g.SightDistance = utils.ClampByte(Params.MinSightDistance, Params.MaxSightDistance, new)
 			case RESPONSIVENESS:
 				g.Responsiveness ^= byte(1 << (rand.Uint32() >> 29))
 			case MUTATION_RATE:
@@ -233,11 +387,15 @@ func Mutate(g *Genome) {
 			case NEURON_COUNT:
 				new := g.NeuronCount
 				new ^= byte(1 << (rand.Uint32() >> 29))
-				g.BrainLength = utils.ClampByte(Params.MinHiddenLayerCount, Params.MaxHiddenLayerCount, new)
+				g.NeuronCount = utils.ClampByte(Params.MinHiddenLayerCount, Params.MaxHiddenLayerCount, new)
 			case NEUROLOGY_LENGTH:
 				new := g.BrainLength
 				new ^= byte(1 << (rand.Uint32() >> 29))
 				g.BrainLength = utils.ClampByte(Params.MinNeuronCount, Params.MaxNeuronCount, new)
+			case ACTIVATION:
+				g.Activation ^= byte(1 << (rand.Uint32() >> 29))
+			case LEARNING_RATE:
+				g.LearningRate ^= byte(1 << (rand.Uint32() >> 29))
 			}
 		}
 	}
@@ -0,0 +1,37 @@
+package simulation
+
+import "testing"
+
+func TestMutate_KeepsTraitsInRange(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		g := MakeRandomGenome()
+		g.MutationRate = 255
+		brainLength := g.BrainLength
+		mutate(g, 1)
+		if g.NeuronCount < Params.MinHiddenLayerCount || g.NeuronCount > Params.MaxHiddenLayerCount {
+			t.Fatalf("NeuronCount mutated to %d, want %d to %d", g.NeuronCount, Params.MinHiddenLayerCount, Params.MaxHiddenLayerCount)
+		}
+		if g.SightDistance < Params.MinSightDistance || g.SightDistance > Params.MaxSightDistance {
+			t.Fatalf("SightDistance mutated to %d, want %d to %d", g.SightDistance, Params.MinSightDistance, Params.MaxSightDistance)
+		}
+		if g.BrainLength < Params.MinNeuronCount || g.BrainLength > Params.MaxNeuronCount {
+			t.Fatalf("BrainLength mutated to %d from %d, want %d to %d", g.BrainLength, brainLength, Params.MinNeuronCount, Params.MaxNeuronCount)
+		}
+		if int(g.BrainLength) != len(g.Brain) {
+			t.Fatalf("BrainLength %d doesn't match the %d genes", g.BrainLength, len(g.Brain))
+		}
+	}
+}
+
+func TestMutate_ChangesNeuronCount(t *testing.T) {
+	g := MakeRandomGenome()
+	start := g.NeuronCount
+	for i := 0; i < 100; i++ {
+		g.MutationRate = 255
+		mutate(g, 1)
+		if g.NeuronCount != start {
+			return
+		}
+	}
+	t.Errorf("NeuronCount stayed %d through 100 mutations", start)
+}
@@ -0,0 +1,199 @@
+// genomediff.go: Decodes brain genes into named connections and compares two genomes trait by trait and gene by gene.
+
+package simulation
+
+import "fmt"
+
+// DecodedGene is a brain gene expressed as the sensor, neuron or action it connects.
+type DecodedGene struct {
+	Source string
+	Sink   string
+	Weight float32
+}
+
+type GeneChange struct {
+	Source string
+	Sink   string
+	From   float32
+	To     float32
+}
+
+type TraitChange struct {
+	Name string
+	From byte
+	To   byte
+}
+
+type GeneDiff struct {
+	Added   []DecodedGene
+	Removed []DecodedGene
+	Changed []GeneChange
+}
+
+// GenomeDiff holds the differences between two genomes. Network compares the genes that survive
+// pruning in CreateNeuralNetworkFromGenome, i.e. the connections the creature actually uses.
+type GenomeDiff struct {
+	Traits   []TraitChange
+	Genes    GeneDiff
+	Network  GeneDiff
+	NeuronsA int // Hidden neurons left after pruning
+	NeuronsB int
+}
+
+func (d DecodedGene) String() string {
+	return fmt.Sprintf("%s -> %s (%+.3f)", d.Source, d.Sink, d.Weight)
+}
+
+func (c GeneChange) String() string {
+	return fmt.Sprintf("%s -> %s (%+.3f => %+.3f)", c.Source, c.Sink, c.From, c.To)
+}
+
+func (c TraitChange) String() string {
+	return fmt.Sprintf("%s: %d => %d", c.Name, c.From, c.To)
+}
+
+func (d GeneDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func (d GenomeDiff) Empty() bool {
+	return len(d.Traits) == 0 && d.Genes.Empty() && d.Network.Empty()
+}
+
+func (d GeneDiff) String() string {
+	str := ""
+	for _, g := range d.Removed {
+		str += fmt.Sprintf("  - %s\n", g)
+	}
+	for _, g := range d.Added {
+		str += fmt.Sprintf("  + %s\n", g)
+	}
+	for _, c := range d.Changed {
+		str += fmt.Sprintf("  ~ %s\n", c)
+	}
+	return str
+}
+
+func (d GenomeDiff) String() string {
+	if d.Empty() {
+		return "Genomes are identical\n"
+	}
+	str := "Traits:\n"
+	for _, t := range d.Traits {
+		str += fmt.Sprintf("  %s\n", t)
+	}
+	str += "Genes:\n" + d.Genes.String()
+	str += fmt.Sprintf("Network: %d => %d hidden neurons\n", d.NeuronsA, d.NeuronsB)
+	str += d.Network.String()
+	return str
+}
+
+// nodeName names a sensor, neuron or action once genes have been through convertGenesToNeuronIDs.
+func nodeName(nodeType, id byte, source bool) string {
+	switch {
+	case nodeType == NEURON:
+		return fmt.Sprintf("N%d", id)
+	case source:
+		return SensorName(id)
+	default:
+		return ActionName(id)
+	}
+}
+
+func decodeConvertedGenes(genes []*Gene) []DecodedGene {
+	decoded := make([]DecodedGene, len(genes))
+	for i, gene := range genes {
+		decoded[i] = DecodedGene{
+			Source: nodeName(gene.SourceType, gene.SourceID, true),
+			Sink:   nodeName(gene.SinkType, gene.SinkID, false),
+			Weight: gene.WeightAsFloat32(),
+		}
+	}
+	return decoded
+}
+
+// DecodeGenes names each of a genome's brain genes using the same ID mapping as CreateNeuralNetworkFromGenome.
+func DecodeGenes(genes []*Gene, neuronCount byte) []DecodedGene {
+	return decodeConvertedGenes(convertGenesToNeuronIDs(genes, neuronCount))
+}
+
+// DecodeNetwork names the genes that survive pruning. Neurons keep their IDs from DecodeGenes.
+func DecodeNetwork(genes []*Gene, neuronCount byte) ([]DecodedGene, int) {
+	pruned, nodeMap := pruneGenes(genes, neuronCount)
+	return decodeConvertedGenes(pruned), len(nodeMap)
+}
+
+// Inspect describes a genome's traits, its decoded genes and its pruned network.
+func (g Genome) Inspect() string {
+	str := "Traits:\n"
+	for _, t := range genomeTraits {
+		str += fmt.Sprintf("  %s: %d\n", t.Name, *t.Field(&g))
+	}
+	str += "Genes:\n"
+	for _, gene := range DecodeGenes(g.Brain, g.NeuronCount) {
+		str += fmt.Sprintf("  %s\n", gene)
+	}
+	network, neurons := DecodeNetwork(g.Brain, g.NeuronCount)
+	str += fmt.Sprintf("Network: %d hidden neurons\n", neurons)
+	for _, gene := range network {
+		str += fmt.Sprintf("  %s\n", gene)
+	}
+	return str
+}
+
+// DiffGenomes compares two genomes. Genes are matched on the connection they make rather than their
+// position in the brain, so a removed gene doesn't make every later gene look changed.
+func DiffGenomes(a, b *Genome) GenomeDiff {
+	d := GenomeDiff{}
+	for _, t := range genomeTraits {
+		from, to := *t.Field(a), *t.Field(b)
+		if from != to {
+			d.Traits = append(d.Traits, TraitChange{Name: t.Name, From: from, To: to})
+		}
+	}
+	d.Genes = diffGenes(DecodeGenes(a.Brain, a.NeuronCount), DecodeGenes(b.Brain, b.NeuronCount))
+
+	var netA, netB []DecodedGene
+	netA, d.NeuronsA = DecodeNetwork(a.Brain, a.NeuronCount)
+	netB, d.NeuronsB = DecodeNetwork(b.Brain, b.NeuronCount)
+	d.Network = diffGenes(netA, netB)
+	return d
+}
+
+// diffGenes pairs identical connections first, then pairs the leftovers with the same
+// source and sink as weight changes. Anything still unpaired was added or removed.
+func diffGenes(a, b []DecodedGene) GeneDiff {
+	d := GeneDiff{}
+	used := make([]bool, len(b))
+	find := func(gene DecodedGene, sameWeight bool) int {
+		for j, other := range b {
+			if !used[j] && other.Source == gene.Source && other.Sink == gene.Sink && (!sameWeight || other.Weight == gene.Weight) {
+				return j
+			}
+		}
+		return -1
+	}
+
+	unmatched := []DecodedGene{}
+	for _, gene := range a {
+		if j := find(gene, true); j >= 0 {
+			used[j] = true
+		} else {
+			unmatched = append(unmatched, gene)
+		}
+	}
+	for _, gene := range unmatched {
+		if j := find(gene, false); j >= 0 {
+			used[j] = true
+			d.Changed = append(d.Changed, GeneChange{Source: gene.Source, Sink: gene.Sink, From: gene.Weight, To: b[j].Weight})
+		} else {
+			d.Removed = append(d.Removed, gene)
+		}
+	}
+	for j, gene := range b {
+		if !used[j] {
+			d.Added = append(d.Added, gene)
+		}
+	}
+	return d
+}
@@ -0,0 +1,124 @@
+package simulation
+
+import (
+	"testing"
+)
+
+func TestParseGenome_RoundTrip(t *testing.T) {
+	g := MakeRandomGenome()
+	for _, s := range []string{g.String(), g.BinaryString()} {
+		parsed, err := ParseGenome(s)
+		if err != nil {
+			t.Fatalf("ParseGenome(%q) returned error: %v", s, err)
+		}
+		if parsed.String() != g.String() {
+			t.Errorf("ParseGenome round trip = %s, want %s", parsed.String(), g.String())
+		}
+	}
+}
+
+func TestParseGenome_OlderFormats(t *testing.T) {
+	gene := "|1|00000010|0|00000011|10000000"
+	want := Gene{SourceType: 1, SourceID: 2, SinkType: 0, SinkID: 3, Weight: 128}
+	cases := []struct {
+		name, s string
+		want    Genome
+	}{
+		{"original", "00000011|00010000|00000101|00001000|00000100|1|00000001" + gene,
+			Genome{OscPeriod: 3, MaxEnergy: 16, SightDistance: 5, Responsiveness: 8, MutationRate: 4, ReproductionType: 1, NeuronCount: Params.MaxHiddenLayerCount, BrainLength: 1}},
+		{"with neuron count", "00000011|00010000|00000101|00001000|00000100|1|00000110|00000001" + gene,
+			Genome{OscPeriod: 3, MaxEnergy: 16, SightDistance: 5, Responsiveness: 8, MutationRate: 4, ReproductionType: 1, NeuronCount: 6, BrainLength: 1}},
+		{"with activation", "00000011|00010000|00000101|00001000|00000100|1|00000010|00000110|00000001" + gene,
+			Genome{OscPeriod: 3, MaxEnergy: 16, SightDistance: 5, Responsiveness: 8, MutationRate: 4, ReproductionType: 1, Activation: 2, NeuronCount: 6, BrainLength: 1}},
+		{"with learning rate", "00000011|00010000|00000101|00001000|00000100|1|00000010|00001001|00000110|00000001" + gene,
+			Genome{OscPeriod: 3, MaxEnergy: 16, SightDistance: 5, Responsiveness: 8, MutationRate: 4, ReproductionType: 1, Activation: 2, LearningRate: 9, NeuronCount: 6, BrainLength: 1}},
+	}
+	for _, c := range cases {
+		g, err := ParseGenome(c.s)
+		if err != nil {
+			t.Errorf("%s: ParseGenome returned error: %v", c.name, err)
+			continue
+		}
+		if len(g.Brain) != 1 || *g.Brain[0] != want {
+			t.Errorf("%s: parsed brain %v, want [%v]", c.name, g.Brain, want)
+			continue
+		}
+		g.Brain, c.want.Brain = nil, nil
+		if g.String() != c.want.String() {
+			t.Errorf("%s: parsed %s, want %s", c.name, g.BinaryString(), c.want.BinaryString())
+		}
+	}
+}
+
+func TestGenome_ToByteArray(t *testing.T) {
+	a := MakeRandomGenome()
+	b := a.Copy()
+	b.Activation++
+	c := a.Copy()
+	c.LearningRate++
+	if string(a.ToByteArray()) == string(b.ToByteArray()) || string(a.ToByteArray()) == string(c.ToByteArray()) {
+		t.Error("ToByteArray should include the activation and learning rate")
+	}
+}
+
+func TestParseGenome_Invalid(t *testing.T) {
+	g := MakeRandomGenome()
+	cases := []string{
+		"",
+		"0101x",
+		g.String() + "1",
+		g.String()[:len(g.String())-len(g.Brain[0].String())],
+	}
+	for _, s := range cases {
+		if _, err := ParseGenome(s); err == nil {
+			t.Errorf("ParseGenome(%q) should have returned an error", s)
+		}
+	}
+}
+
+func TestDiffGenomes(t *testing.T) {
+	a := &Genome{
+		MutationRate: 10,
+		NeuronCount:  0,
+		BrainLength:  2,
+		Brain: []*Gene{
+			{SourceType: SENSOR, SourceID: LOC_X, SinkType: ACTION, SinkID: MOVE_WEST, Weight: 255},
+			{SourceType: SENSOR, SourceID: AGE, SinkType: ACTION, SinkID: MOVE_EAST, Weight: 0},
+		},
+	}
+	b := a.Copy()
+	b.MutationRate = 11
+	b.Brain[0].Weight = 0
+	b.Brain[1] = &Gene{SourceType: SENSOR, SourceID: RANDOM, SinkType: ACTION, SinkID: MOVE_NORTH, Weight: 255}
+
+	d := DiffGenomes(a, b)
+	if len(d.Traits) != 1 || d.Traits[0].Name != "MutationRate" {
+		t.Errorf("Expected only MutationRate to differ, got %v", d.Traits)
+	}
+	if len(d.Genes.Changed) != 1 || d.Genes.Changed[0].Source != "LOC_X" || d.Genes.Changed[0].Sink != "MOVE_WEST" {
+		t.Errorf("Expected LOC_X -> MOVE_WEST to change weight, got %v", d.Genes.Changed)
+	}
+	if len(d.Genes.Removed) != 1 || d.Genes.Removed[0].Source != "AGE" {
+		t.Errorf("Expected AGE -> MOVE_EAST to be removed, got %v", d.Genes.Removed)
+	}
+	if len(d.Genes.Added) != 1 || d.Genes.Added[0].Sink != "MOVE_NORTH" {
+		t.Errorf("Expected RANDOM -> MOVE_NORTH to be added, got %v", d.Genes.Added)
+	}
+	if d.Network.Empty() {
+		t.Error("Expected the pruned networks to differ")
+	}
+	if !DiffGenomes(a, a.Copy()).Empty() {
+		t.Error("A genome should not differ from its copy")
+	}
+}
+
+func TestParseGenome_ReadmeExample(t *testing.T) {
+	example := "00101011|01001010|00000111|11110110|01010000|1|00000000|00000000|00000001|00000010|0|00000110|1|10110110|10000111|1|10110110|0|01110001|10101110"
+	g, err := ParseGenome(example)
+	if err != nil {
+		t.Fatalf("ParseGenome returned error for the README example: %v", err)
+	}
+	if g.BinaryString() != example {
+		t.Errorf("BinaryString() = %s, want %s", g.BinaryString(), example)
+	}
+}
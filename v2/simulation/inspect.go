@@ -0,0 +1,116 @@
+// inspect.go: Read only snapshots of a creature's genome traits and brain state, for inspecting it while the simulation runs.
+
+package simulation
+
+import (
+	"math"
+	"sort"
+)
+
+// TraitValue is one of a genome's traits, decoded.
+type TraitValue struct {
+	Name  string
+	Value byte
+}
+
+// Traits lists the genome's traits in the order they're serialized.
+func (g Genome) Traits() []TraitValue {
+	traits := make([]TraitValue, len(genomeTraits))
+	for i, t := range genomeTraits {
+		traits[i] = TraitValue{Name: t.Name, Value: *t.Field(&g)}
+	}
+	return traits
+}
+
+// BrainNode is a sensor, neuron or action with its value from the creature's last step: the sensor
+// reading, the neuron's output, or the action level before the action applies it.
+type BrainNode struct {
+	NetNode
+	Value float32
+}
+
+// BrainEdge is a connection with its current weight, and the signal it carried on the last step.
+type BrainEdge struct {
+	Source, Target int // Indexes into BrainState.Nodes
+	Weight         float32
+	Signal         float32 // The edge's input times its weight
+}
+
+// BrainState is a creature's net as it was after its last FeedForward. Nodes are sorted sensors
+// first, then neurons, then actions, as in NetGraph.
+type BrainState struct {
+	Nodes []BrainNode
+	Edges []BrainEdge
+}
+
+// Brain reads the state of the creature's net without running it, so it doesn't disturb the simulation.
+func (c *Creature) Brain() BrainState {
+	n := &c.Nnet
+	state := BrainState{Nodes: []BrainNode{}, Edges: []BrainEdge{}}
+	index := map[string]int{}
+	node := func(nodeType, id byte, source bool) string {
+		graphNode := graphNode(nodeType, id, source)
+		if _, ok := index[graphNode.ID]; !ok {
+			index[graphNode.ID] = len(state.Nodes)
+			value := float32(0)
+			switch {
+			case nodeType == NEURON && int(id) < len(n.Neurons):
+				value = n.Neurons[id].Output
+			case source && int(id) < len(c.sensorValuesBuf):
+				value = c.sensorValuesBuf[id]
+			case !source && int(id) < len(c.actionLevelsBuf):
+				value = c.actionLevelsBuf[id]
+			}
+			state.Nodes = append(state.Nodes, BrainNode{NetNode: graphNode, Value: value})
+		}
+		return graphNode.ID
+	}
+
+	type edge struct {
+		source, target string
+		weight, signal float32
+	}
+	edges := make([]edge, len(n.Weights))
+	for i := range n.Weights {
+		sinkType := byte(ACTION)
+		if i < n.NeuronEdgeCount {
+			sinkType = NEURON
+		}
+		edges[i] = edge{
+			source: node(n.SourceTypes[i], n.SourceIDs[i], true),
+			target: node(sinkType, n.SinkIDs[i], false),
+			weight: n.Weights[i],
+		}
+		if i < len(c.edgeInputsBuf) {
+			edges[i].signal = c.edgeInputsBuf[i] * n.Weights[i]
+		}
+	}
+
+	kindOrder := map[string]int{NODE_SENSOR: 0, NODE_NEURON: 1, NODE_ACTION: 2}
+	sort.SliceStable(state.Nodes, func(i, j int) bool {
+		a, b := state.Nodes[i], state.Nodes[j]
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		return a.ID < b.ID
+	})
+	for i, node := range state.Nodes {
+		index[node.ID] = i
+	}
+	for _, e := range edges {
+		state.Edges = append(state.Edges, BrainEdge{Source: index[e.source], Target: index[e.target], Weight: e.weight, Signal: e.signal})
+	}
+	return state
+}
+
+// DominantAction is the ID of the action with the strongest level on the creature's last step, or -1
+// if none had a level.
+func (c *Creature) DominantAction() int {
+	best, strongest := -1, float32(0)
+	for id, level := range c.actionLevelsBuf {
+		if abs := float32(math.Abs(float64(level))); abs > strongest {
+			best, strongest = id, abs
+		}
+	}
+	return best
+}
@@ -0,0 +1,157 @@
+// islands.go: Runs several simulations side by side as islands, migrating genomes between them every few generations.
+
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+type MigrationTopology int
+
+const (
+	MigrationRing   MigrationTopology = iota // Each island sends to the next, the last to the first
+	MigrationFull                            // Each island sends to every other
+	MigrationRandom                          // Each island sends to another picked at random every migration
+)
+
+var topologyNames = []string{"ring", "full", "random"}
+
+func (t MigrationTopology) String() string {
+	if int(t) < len(topologyNames) {
+		return topologyNames[t]
+	}
+	return fmt.Sprintf("MigrationTopology(%d)", int(t))
+}
+
+func ParseMigrationTopology(s string) (MigrationTopology, error) {
+	for i, name := range topologyNames {
+		if strings.EqualFold(s, name) {
+			return MigrationTopology(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown migration topology %q", s)
+}
+
+// Archipelago is a set of islands, each a Simulation with its own grid, populations and stats.
+// Islands run their generations concurrently; they only interact through migration. They share
+// Params, so their schedules can only change their own populations and grids.
+type Archipelago struct {
+	Islands  []*Simulation
+	Topology MigrationTopology
+	Interval int // Generations between migrations, 0 to never migrate
+	Migrants int // Genomes each island sends to each destination, per population
+}
+
+// NewArchipelago creates the islands one after another, as New configures the shared registry. It
+// returns an error if the schedule sets Params, see checkIslandSchedule.
+func NewArchipelago(islands int, topology MigrationTopology, interval, migrants int) (*Archipelago, error) {
+	if err := checkIslandSchedule(Params.Schedule); err != nil {
+		return nil, err
+	}
+	a := &Archipelago{Topology: topology, Interval: interval, Migrants: migrants}
+	for i := 0; i < islands; i++ {
+		sim := New()
+		sim.Name = fmt.Sprintf("island-%d", i+1)
+		a.Islands = append(a.Islands, sim)
+	}
+	return a, nil
+}
+
+// Generation is the generation every island has reached.
+func (a *Archipelago) Generation() int {
+	if len(a.Islands) == 0 {
+		return 0
+	}
+	return a.Islands[0].Generation
+}
+
+// RunGeneration runs a generation on every island at once, then migrates if one is due. An island
+// whose population dies out is returned as an error, naming the island and generation.
+func (a *Archipelago) RunGeneration() error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(a.Islands))
+	for i, island := range a.Islands {
+		wg.Add(1)
+		go func(i int, sim *Simulation) {
+			defer wg.Done()
+			// The simulation panics when a population goes extinct
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("%s generation %d: %v", sim.Name, sim.Generation, r)
+				}
+			}()
+			sim.RunGeneration()
+		}(i, island)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if a.Interval > 0 && a.Generation()%a.Interval == 0 {
+		a.Migrate()
+	}
+	return nil
+}
+
+// destinations lists the islands island i sends migrants to.
+func (a *Archipelago) destinations(i int) []int {
+	k := len(a.Islands)
+	if k < 2 {
+		return nil
+	}
+	switch a.Topology {
+	case MigrationFull:
+		dests := []int{}
+		for j := 0; j < k; j++ {
+			if j != i {
+				dests = append(dests, j)
+			}
+		}
+		return dests
+	case MigrationRandom:
+		j := rand.Intn(k - 1)
+		if j >= i {
+			j++
+		}
+		return []int{j}
+	default:
+		return []int{(i + 1) % k}
+	}
+}
+
+// Migrate copies the genomes of randomly chosen creatures on each island into randomly chosen
+// creatures of the same population on its destination islands. Migrants are all chosen before any
+// arrive, so a genome moves at most one island per migration. It should be called between
+// generations, before the new creatures have stepped.
+func (a *Archipelago) Migrate() {
+	type arrival struct {
+		island, population int
+		genomes            []*Genome
+	}
+	arrivals := []arrival{}
+	for i, island := range a.Islands {
+		for _, dest := range a.destinations(i) {
+			for p, pop := range island.Populations {
+				if p >= len(a.Islands[dest].Populations) || len(pop.Creatures) == 0 {
+					continue
+				}
+				genomes := make([]*Genome, a.Migrants)
+				for m := range genomes {
+					genomes[m] = pop.Creatures[rand.Intn(len(pop.Creatures))].Genome.Copy()
+				}
+				arrivals = append(arrivals, arrival{dest, p, genomes})
+			}
+		}
+	}
+	for _, arr := range arrivals {
+		pop := a.Islands[arr.island].Populations[arr.population]
+		for _, g := range arr.genomes {
+			pop.replaceGenome(rand.Intn(len(pop.Creatures)), g)
+		}
+		pop.Immigrants += len(arr.genomes)
+	}
+}
@@ -0,0 +1,134 @@
+package simulation
+
+import (
+	"strings"
+	"testing"
+)
+
+// smallWorld shrinks the simulation so whole generations run quickly. It returns a func restoring the parameters.
+func smallWorld() func() {
+	saved := *Params
+	Params.GridWidth, Params.GridHeight = 60, 40
+	Params.MaxPopulation, Params.StartingPopulation = 50, 50
+	Params.MaxAge = 20
+	Params.Challenge = AllSurvive
+	return func() { *Params = saved }
+}
+
+func newArchipelago(t *testing.T, islands int, topology MigrationTopology, interval, migrants int) *Archipelago {
+	t.Helper()
+	a, err := NewArchipelago(islands, topology, interval, migrants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func countGenome(sim *Simulation, g *Genome) int {
+	n := 0
+	for _, c := range sim.Creatures() {
+		if c.Genome.String() == g.String() {
+			n++
+		}
+	}
+	return n
+}
+
+func TestArchipelago_Migrate(t *testing.T) {
+	defer smallWorld()()
+	marker := MakeRandomGenome()
+	// Migrants replace random creatures, so an island may lose its own genomes to arrivals, and
+	// an arrival may overwrite an earlier one.
+	cases := []struct {
+		topology   MigrationTopology
+		min, max   []int // Creatures with the marker genome on each island after migrating
+		immigrants []int
+	}{
+		{MigrationRing, []int{47, 1, 0}, []int{50, 3, 0}, []int{3, 3, 3}},
+		{MigrationFull, []int{44, 1, 1}, []int{50, 3, 3}, []int{6, 6, 6}},
+	}
+	for _, tc := range cases {
+		a := newArchipelago(t, 3, tc.topology, 1, 3)
+		for i := range a.Islands[0].Populations[0].Creatures {
+			a.Islands[0].Populations[0].replaceGenome(i, marker)
+		}
+		a.Migrate()
+		for i, island := range a.Islands {
+			if got := countGenome(island, marker); got < tc.min[i] || got > tc.max[i] {
+				t.Errorf("%s: island %d has %d creatures with the marker genome, want %d to %d", tc.topology, i+1, got, tc.min[i], tc.max[i])
+			}
+			if got := island.Populations[0].Immigrants; got != tc.immigrants[i] {
+				t.Errorf("%s: island %d counted %d immigrants, want %d", tc.topology, i+1, got, tc.immigrants[i])
+			}
+		}
+	}
+}
+
+func TestArchipelago_RunGeneration(t *testing.T) {
+	defer smallWorld()()
+	a := newArchipelago(t, 3, MigrationRandom, 2, 2)
+	for a.Generation() < 4 {
+		if err := a.RunGeneration(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Migration happens after generations 2 and 4, so only generation 3 starts with immigrants
+	immigrants := make([]int, 4)
+	for _, island := range a.Islands {
+		if island.Generation != 4 || len(island.Stats.History) != 4 {
+			t.Fatalf("%s reached generation %d with %d stats rows, want 4 and 4", island.Name, island.Generation, len(island.Stats.History))
+		}
+		for i, st := range island.Stats.History {
+			immigrants[i] += st.Immigrants
+		}
+	}
+	if immigrants[2] != 6 || immigrants[0]+immigrants[1]+immigrants[3] != 0 {
+		t.Errorf("Immigrants per generation across the islands = %v, want 6 in generation 3 only", immigrants)
+	}
+}
+
+// Run with -race: each island's schedule changes only its own populations.
+func TestArchipelago_ScheduleWithIslands(t *testing.T) {
+	defer smallWorld()()
+	Params.Schedule = []ScheduleEvent{
+		{Name: "settle", AtGeneration: 1, Set: map[string]string{"MaxAge": "30"}},
+	}
+	if _, err := NewArchipelago(2, MigrationRing, 0, 0); err == nil {
+		t.Fatal("A schedule setting Params should be rejected for islands")
+	}
+
+	Params.Schedule = []ScheduleEvent{
+		{Name: "left", AtGeneration: 1, Set: map[string]string{"Challenge": "LeftSurvive", "Map": "NO_WALLS"}},
+	}
+	Params.MaxAge = 200
+	a := newArchipelago(t, 2, MigrationRing, 0, 0)
+	// The first island finishes early, so its schedule fires while the second is still stepping
+	for _, c := range a.Islands[0].Creatures()[1:] {
+		c.Alive = false
+	}
+	for a.Generation() < 2 {
+		if err := a.RunGeneration(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, island := range a.Islands {
+		if got := island.Populations[0].Config.Challenge; got != LeftSurvive {
+			t.Errorf("%s challenge is %v after its schedule fired, want LeftSurvive", island.Name, got)
+		}
+	}
+	if Params.Challenge != AllSurvive || Params.MaxAge != 200 {
+		t.Error("Island schedules shouldn't change Params")
+	}
+}
+
+func TestArchipelago_Extinction(t *testing.T) {
+	defer smallWorld()()
+	a := newArchipelago(t, 2, MigrationRing, 0, 0)
+	for _, c := range a.Islands[1].Creatures() {
+		c.Alive = false
+	}
+	err := a.RunGeneration()
+	if err == nil || !strings.Contains(err.Error(), "island-2 generation 1") || strings.Contains(err.Error(), "island-1") {
+		t.Errorf("Extinction on island 2 returned %v, want an error naming island-2 generation 1", err)
+	}
+}
@@ -2,7 +2,10 @@
 
 package simulation
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 const (
 	// Neurons are treated differently to sensors/actions.
@@ -16,9 +19,28 @@ type Neuron struct {
 	Driven bool
 }
 
+// NeuralNet is a creature's pruned brain. Edges keeps the genes for printing and export, while
+// FeedForward runs on the compiled form: parallel arrays with one entry per edge and a slice of
+// neurons indexed by ID, so a forward pass needs no map lookups or pointer chasing.
 type NeuralNet struct {
-	Edges         []*Gene
-	HiddenNeurons map[byte]*Neuron
+	Edges        []*Gene
+	Neurons      []Neuron
+	Activation   ActivationType
+	LearningRate float32 // Hebbian learning rate, 0 if the net doesn't learn
+
+	// Compiled edges. Those before NeuronEdgeCount feed neurons, the rest feed actions.
+	Sensors         []byte // Each sensor the edges read from, once
+	SourceTypes     []byte
+	SourceIDs       []byte
+	SinkIDs         []byte
+	Weights         []float32 // Changed by learning, so exports read these rather than the genes
+	GeneIndexes     []int     // Index of the gene in the genome's Brain each edge was built from
+	NeuronEdgeCount int
+
+	// Hidden neurons in dependency order. The edges into NeuronOrder[k] end at NeuronEdgeEnds[k],
+	// and start where the previous neuron's end.
+	NeuronOrder    []int
+	NeuronEdgeEnds []int
 }
 
 type Node struct {
@@ -42,7 +64,7 @@ func (n NeuralNet) String() string {
 		str += fmt.Sprintf("%s ", val.String())
 	}
 	str += "]\n    | Neurons: "
-	for _, val := range n.HiddenNeurons {
+	for _, val := range n.Neurons {
 		str += fmt.Sprintf("%s ", val.String())
 	}
 	str += "\n"
@@ -66,15 +88,30 @@ func CreateInitialNeuronOutput() float32 { return 0.5 }
 
 func CreateNeuralNetworkFromGenome(genes []*Gene, neuronCount byte) *NeuralNet {
 	neuralGenes := convertGenesToNeuronIDs(genes, neuronCount)
-	nodeMap := createNodeMap(neuralGenes)
-	finalGenes := removeUselessGenes(neuralGenes, nodeMap)
+	// Remember where each gene came from, so learned weights can be written back to the genome
+	geneIndexes := make(map[*Gene]int, len(neuralGenes))
+	for i, gene := range neuralGenes {
+		geneIndexes[gene] = i
+	}
+	finalGenes, nodeMap := pruneConvertedGenes(neuralGenes)
 	// The remaining nodes in nodeMap will need to be re-indexed
 	setNodeNewIDValues(nodeMap)
-	neuralNet := createNeuralNetworkFromGenesAndNodeMap(finalGenes, nodeMap)
+	neuralNet := createNeuralNetworkFromGenesAndNodeMap(finalGenes, nodeMap, geneIndexes)
 	return neuralNet
 }
 
-func createNeuralNetworkFromGenesAndNodeMap(g []*Gene, n NodeMap) *NeuralNet {
+// pruneGenes maps raw genes onto sensor, neuron and action IDs and removes the useless neurons.
+// Neurons keep their pre re-indexing IDs.
+func pruneGenes(genes []*Gene, neuronCount byte) ([]*Gene, NodeMap) {
+	return pruneConvertedGenes(convertGenesToNeuronIDs(genes, neuronCount))
+}
+
+func pruneConvertedGenes(neuralGenes []*Gene) ([]*Gene, NodeMap) {
+	nodeMap := createNodeMap(neuralGenes)
+	return removeUselessGenes(neuralGenes, nodeMap), nodeMap
+}
+
+func createNeuralNetworkFromGenesAndNodeMap(g []*Gene, n NodeMap, geneIndexes map[*Gene]int) *NeuralNet {
 	nnet := NeuralNet{}
 
 	// We do this in two phases, first we add the -> neurons, then we add the -> actions. This
@@ -92,6 +129,7 @@ func createNeuralNetworkFromGenesAndNodeMap(g []*Gene, n NodeMap) *NeuralNet {
 			}
 			// Add the new gene to the nnet
 			nnet.Edges = append(nnet.Edges, &new)
+			nnet.GeneIndexes = append(nnet.GeneIndexes, geneIndexes[gene])
 		}
 	}
 	for _, gene := range g {
@@ -101,21 +139,138 @@ func createNeuralNetworkFromGenesAndNodeMap(g []*Gene, n NodeMap) *NeuralNet {
 				new.SourceID = n[gene.SourceID].NewID
 			}
 			nnet.Edges = append(nnet.Edges, &new)
+			nnet.GeneIndexes = append(nnet.GeneIndexes, geneIndexes[gene])
 		}
 
 	}
-	nnet.HiddenNeurons = make(map[byte]*Neuron, len(n))
+	nnet.Neurons = make([]Neuron, len(n))
 	// Create the neurons
 	for _, node := range n {
-		neuron := &Neuron{
+		nnet.Neurons[node.NewID] = Neuron{
 			Output: CreateInitialNeuronOutput(),
 			Driven: node.InputCount != 0,
 		}
-		nnet.HiddenNeurons[node.NewID] = neuron
 	}
+	nnet.compile()
 	return &nnet
 }
 
+// compile flattens Edges into the arrays FeedForward reads. Edges must already be ordered with
+// the edges into neurons first; those are then grouped by sink in NeuronOrder.
+func (n *NeuralNet) compile() {
+	n.NeuronOrder = n.orderNeurons()
+	position := make([]int, len(n.Neurons))
+	for k, id := range n.NeuronOrder {
+		position[id] = k
+	}
+	n.NeuronEdgeCount = 0
+	for _, gene := range n.Edges {
+		if gene.SinkType == NEURON {
+			n.NeuronEdgeCount++
+		}
+	}
+	neuronEdges := edgesByIndex{edges: n.Edges[:n.NeuronEdgeCount], geneIndexes: n.GeneIndexes[:n.NeuronEdgeCount]}
+	sort.Stable(edgesBySinkPosition{neuronEdges, position})
+
+	n.NeuronEdgeEnds = make([]int, len(n.NeuronOrder))
+	for _, gene := range n.Edges[:n.NeuronEdgeCount] {
+		n.NeuronEdgeEnds[position[gene.SinkID]]++
+	}
+	for k := 1; k < len(n.NeuronEdgeEnds); k++ {
+		n.NeuronEdgeEnds[k] += n.NeuronEdgeEnds[k-1]
+	}
+
+	n.Sensors = []byte{}
+	n.SourceTypes = make([]byte, len(n.Edges))
+	n.SourceIDs = make([]byte, len(n.Edges))
+	n.SinkIDs = make([]byte, len(n.Edges))
+	n.Weights = make([]float32, len(n.Edges))
+	used := map[byte]bool{}
+	for i, gene := range n.Edges {
+		if gene.SourceType == SENSOR && !used[gene.SourceID] {
+			used[gene.SourceID] = true
+			n.Sensors = append(n.Sensors, gene.SourceID)
+		}
+		n.SourceTypes[i] = gene.SourceType
+		n.SourceIDs[i] = gene.SourceID
+		n.SinkIDs[i] = gene.SinkID
+		n.Weights[i] = gene.WeightAsFloat32()
+	}
+}
+
+// orderNeurons lists the hidden neurons so that, outside of cycles, each comes after every neuron
+// feeding it. It's a depth first search back along each neuron's inputs; an input already on the
+// search path closes a cycle, and that edge ends up pointing backwards in the order.
+func (n *NeuralNet) orderNeurons() []int {
+	inputs := make([][]int, len(n.Neurons))
+	for _, gene := range n.Edges {
+		if gene.SourceType == NEURON && gene.SinkType == NEURON {
+			inputs[gene.SinkID] = append(inputs[gene.SinkID], int(gene.SourceID))
+		}
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(n.Neurons))
+	order := make([]int, 0, len(n.Neurons))
+	var visit func(id int)
+	visit = func(id int) {
+		if state[id] != unvisited {
+			return
+		}
+		state[id] = visiting
+		for _, source := range inputs[id] {
+			visit(source)
+		}
+		state[id] = visited
+		order = append(order, id)
+	}
+	for id := range n.Neurons {
+		visit(id)
+	}
+	return order
+}
+
+// DelayedEdges counts the neuron to neuron edges that read the previous step's output under
+// EvaluationTopological, i.e. those closing a cycle (including self loops).
+func (n NeuralNet) DelayedEdges() int {
+	position := make([]int, len(n.Neurons))
+	for k, id := range n.NeuronOrder {
+		position[id] = k
+	}
+	count := 0
+	for i := 0; i < n.NeuronEdgeCount; i++ {
+		if n.SourceTypes[i] == NEURON && position[n.SourceIDs[i]] >= position[n.SinkIDs[i]] {
+			count++
+		}
+	}
+	return count
+}
+
+// edgesByIndex sorts edges along with the genome index of each.
+type edgesByIndex struct {
+	edges       []*Gene
+	geneIndexes []int
+}
+
+func (e edgesByIndex) Len() int { return len(e.edges) }
+
+func (e edgesByIndex) Swap(i, j int) {
+	e.edges[i], e.edges[j] = e.edges[j], e.edges[i]
+	e.geneIndexes[i], e.geneIndexes[j] = e.geneIndexes[j], e.geneIndexes[i]
+}
+
+type edgesBySinkPosition struct {
+	edgesByIndex
+	position []int
+}
+
+func (e edgesBySinkPosition) Less(i, j int) bool {
+	return e.position[e.edges[i].SinkID] < e.position[e.edges[j].SinkID]
+}
+
 func setNodeNewIDValues(n NodeMap) {
 	i := 0
 	for _, node := range n {
@@ -219,8 +374,11 @@ func createNodeMap(neuralGenes []*Gene) NodeMap {
 	return nMap
 }
 
+// convertGenesToNeuronIDs maps each gene's source and sink onto a neuron, or onto one of the enabled sensors and actions.
 func convertGenesToNeuronIDs(genes []*Gene, neuronCount byte) []*Gene {
 	newGenes := make([]*Gene, len(genes))
+	sensorIDs := enabledSensorIDs()
+	actionIDs := enabledActionIDs()
 
 	for i, gene := range genes {
 		new := *gene // Make a copy
@@ -230,7 +388,7 @@ func convertGenesToNeuronIDs(genes []*Gene, neuronCount byte) []*Gene {
 		} else {
 			// Reset the type in case of Neurons with neuronCount == 0
 			new.SourceType = 1
-			new.SourceID %= SENSOR_COUNT
+			new.SourceID = sensorIDs[int(new.SourceID)%len(sensorIDs)]
 		}
 
 		if new.SinkType == NEURON && neuronCount > 0 {
@@ -239,7 +397,7 @@ func convertGenesToNeuronIDs(genes []*Gene, neuronCount byte) []*Gene {
 		} else {
 			// Reset the type in case of Neurons with neuronCount == 0
 			new.SinkType = 1
-			new.SinkID %= ACTION_COUNT
+			new.SinkID = actionIDs[int(new.SinkID)%len(actionIDs)]
 		}
 		newGenes[i] = &new
 	}
@@ -1,5 +1,12 @@
 package simulation
 
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
 var Params = &Parameters{
 	MaxGenerations:                  10000, // For testing purposes
 	MaxPopulation:                   1000,
@@ -24,6 +31,18 @@ var Params = &Parameters{
 	SexualReproductionSimilarityMax: 0.98,
 	ResponseCurveKFactor:            2,
 	Challenge:                       FarLeftSurvive,
+	Activation:                      ActivationTanh,
+	Recurrence:                      RecurrenceStateful,
+	Evaluation:                      EvaluationBatched,
+	Plasticity:                      false,
+	BaseLearningRate:                0.01,
+	Lamarckian:                      false,
+	PheromoneDiffusion:              0.2,
+	PheromoneDecay:                  0.02,
+	PheromoneEmitAmount:             0.5,
+	KillProbability:                 0.5,
+	KillEnergyReward:                25,
+	MapFile:                         "",
 }
 
 type Parameters struct {
@@ -50,4 +69,115 @@ type Parameters struct {
 	SexualReproductionSimilarityMax float32 // The maximum genome similarity required for sexual reproduction (i.e. prevent incest?)
 	ResponseCurveKFactor            float32
 	Challenge                       ChallengeType
+	Activation                      ActivationType     // Hidden neuron activation, or ActivationFromGenome to let it evolve
+	Recurrence                      RecurrenceMode     // Whether neuron outputs carry over between steps
+	Evaluation                      EvaluationMode     // Whether hidden neurons update all at once or in dependency order
+	Plasticity                      bool               // Hebbian learning changes edge weights during a creature's life
+	BaseLearningRate                float32            // Learning rate for a genome LearningRate of 255
+	Lamarckian                      bool               // Children inherit the learned weights rather than their parent's genes
+	PheromoneDiffusion              float32            // Fraction of each pheromone cell that spreads to its neighbours every step
+	PheromoneDecay                  float32            // Fraction of each pheromone cell lost every step
+	PheromoneEmitAmount             float32            // Pheromone deposited by one EMIT_PHEROMONE, cells hold at most 1
+	KillProbability                 float32            // Chance a KILL_FORWARD attack kills the creature in front
+	KillEnergyReward                float32            // Energy gained for a kill, up to the killer's MaxEnergy
+	MapFile                         string             // JSON map file of walls and moving barriers, or "" for the default map
+	Populations                     []PopulationConfig // Populations sharing the grid, a single one built from these parameters if empty
+	Schedule                        []ScheduleEvent    // Changes to the challenge, map and parameters as the run goes on
+	Sensors                         map[string]bool    // Enables or disables sensors by name or code, on top of the registry defaults
+	Actions                         map[string]bool    // Enables or disables actions by name or code, on top of the registry defaults
+}
+
+// PopulationConfigs returns the configured populations with their fallbacks filled in.
+func (p *Parameters) PopulationConfigs() []PopulationConfig {
+	if len(p.Populations) == 0 {
+		return []PopulationConfig{{
+			Name:             "default",
+			Size:             p.MaxPopulation,
+			StartingSize:     p.StartingPopulation,
+			Challenge:        p.Challenge,
+			BaseMutationRate: p.BaseMutationRate,
+		}}
+	}
+	configs := make([]PopulationConfig, len(p.Populations))
+	for i, config := range p.Populations {
+		if config.Name == "" {
+			config.Name = fmt.Sprintf("population-%d", i+1)
+		}
+		if config.Size == 0 {
+			config.Size = p.MaxPopulation
+		}
+		if config.StartingSize == 0 {
+			config.StartingSize = config.Size
+		}
+		if config.BaseMutationRate == 0 {
+			config.BaseMutationRate = p.BaseMutationRate
+		}
+		configs[i] = config
+	}
+	return configs
+}
+
+// enumParsers read the enum parameters by name or number.
+var enumParsers = map[reflect.Type]func(string) (reflect.Value, error){
+	reflect.TypeOf(ChallengeType(0)):  enumParser(ParseChallenge),
+	reflect.TypeOf(ActivationType(0)): enumParser(ParseActivation),
+	reflect.TypeOf(RecurrenceMode(0)): enumParser(ParseRecurrence),
+	reflect.TypeOf(EvaluationMode(0)): enumParser(ParseEvaluation),
+}
+
+func enumParser[T any](parse func(string) (T, error)) func(string) (reflect.Value, error) {
+	return func(s string) (reflect.Value, error) {
+		v, err := parse(s)
+		return reflect.ValueOf(v), err
+	}
+}
+
+// Set assigns a parameter from its string form, by field name (case insensitive), so runs can be
+// configured without editing this file. Only number, bool, string and enum fields can be set; enums
+// such as Challenge take the constant's name or number.
+func (p *Parameters) Set(name, value string) error {
+	v := reflect.ValueOf(p).Elem()
+	field := v.FieldByNameFunc(func(n string) bool { return strings.EqualFold(n, name) })
+	if !field.IsValid() {
+		return fmt.Errorf("unknown parameter %q", name)
+	}
+	if parse, ok := enumParsers[field.Type()]; ok {
+		parsed, err := parse(value)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", name, err)
+		}
+		field.Set(parsed)
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", name, err)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", name, err)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", name, err)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", name, err)
+		}
+		field.SetBool(b)
+	case reflect.String:
+		field.SetString(value)
+	default:
+		return fmt.Errorf("parameter %s can't be set from a string", name)
+	}
+	return nil
 }
@@ -0,0 +1,74 @@
+package simulation
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParameters_Set(t *testing.T) {
+	p := *Params
+	cases := []struct {
+		name, value string
+		check       func() bool
+	}{
+		{"MaxPopulation", "500", func() bool { return p.MaxPopulation == 500 }},
+		{"basemutationrate", "0.01", func() bool { return p.BaseMutationRate == 0.01 }},
+		{"MaxEnergy", "200", func() bool { return p.MaxEnergy == 200 }},
+		{"Plasticity", "true", func() bool { return p.Plasticity }},
+		{"Challenge", "5", func() bool { return p.Challenge == AllSurvive }},
+		{"Challenge", "center", func() bool { return p.Challenge == Center }},
+		{"Activation", "ReLU", func() bool { return p.Activation == ActivationReLU }},
+		{"Activation", "genome", func() bool { return p.Activation == ActivationFromGenome }},
+		{"Recurrence", "stateless", func() bool { return p.Recurrence == RecurrenceStateless }},
+		{"Evaluation", "1", func() bool { return p.Evaluation == EvaluationTopological }},
+		{"MapFile", "maps/doors.json", func() bool { return p.MapFile == "maps/doors.json" }},
+	}
+	for _, c := range cases {
+		if err := p.Set(c.name, c.value); err != nil {
+			t.Errorf("Set(%s, %s) returned error: %v", c.name, c.value, err)
+		} else if !c.check() {
+			t.Errorf("Set(%s, %s) didn't set the field", c.name, c.value)
+		}
+	}
+
+	for _, c := range [][2]string{{"NoSuchParameter", "1"}, {"MaxEnergy", "256"}, {"MaxAge", "ten"}, {"Sensors", "x"}, {"Challenge", "Nowhere"}, {"Activation", "9"}} {
+		if err := p.Set(c[0], c[1]); err == nil {
+			t.Errorf("Set(%s, %s) should have returned an error", c[0], c[1])
+		}
+	}
+}
+
+func TestStats_CSVRoundTrip(t *testing.T) {
+	r := StatsRecorder{}
+	var out bytes.Buffer
+	if err := r.WriteCSV(&out); err != nil {
+		t.Fatal(err)
+	}
+	rows := []GenerationStats{
+		{Generation: 1, Population: "predator", Size: 10, Survivors: 2, SurvivalRate: 0.2},
+		{Generation: 1, Population: "prey", Size: 90, Survivors: 80, SurvivalRate: 80.0 / 90, Kills: 1, Killed: 3, Immigrants: 4, Diversity: 0.25, Species: 3, BrainSize: 4.5},
+		{Generation: 2, Population: "predator", Size: 10, Survivors: 10, SurvivalRate: 1},
+		{Generation: 2, Population: "prey", Size: 90, Survivors: 85, SurvivalRate: 85.0 / 90},
+	}
+	for _, row := range rows {
+		if err := r.Record(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := ReadStatsCSV(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != len(rows) || history[1].Population != "prey" || history[1].Killed != 3 || history[1].Immigrants != 4 ||
+		history[1].Diversity != 0.25 || history[1].Species != 3 || history[1].BrainSize != 4.5 {
+		t.Errorf("ReadStatsCSV = %+v, want %+v", history, rows)
+	}
+	// Generation 1 has 82% survival over both populations, generation 2 has 95%
+	if got := GenerationsToSurvival(history, 0.9); got != 2 {
+		t.Errorf("GenerationsToSurvival(0.9) = %d, want 2", got)
+	}
+	if got := GenerationsToSurvival(history, 0.99); got != 0 {
+		t.Errorf("GenerationsToSurvival(0.99) = %d, want 0", got)
+	}
+}
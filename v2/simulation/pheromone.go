@@ -0,0 +1,60 @@
+// pheromone.go: The pheromone layer creatures can deposit scent into, and the sensors that read it back.
+
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"math"
+)
+
+// Index of the pheromone layer in Grid.Layers, added by InitializeGrid.
+const PHEROMONE_LAYER = 0
+
+func pheromoneLayer(g *grid.Grid) *grid.Layer {
+	if g == nil || len(g.Layers) <= PHEROMONE_LAYER {
+		return nil
+	}
+	return g.Layers[PHEROMONE_LAYER]
+}
+
+// updatePheromoneRates copies the diffusion and decay rates from Params into the layer, so changes made
+// during a run (by a schedule, say) take effect on the next step.
+func updatePheromoneRates(g *grid.Grid) {
+	if layer := pheromoneLayer(g); layer != nil {
+		layer.Diffusion, layer.Decay = Params.PheromoneDiffusion, Params.PheromoneDecay
+	}
+}
+
+// emitPheromone deposits scent where the creature stands, with a probability set by the action level.
+func emitPheromone(s *Simulation, c *Creature, level float32, move *Movement) {
+	layer := pheromoneLayer(s.Grid)
+	if layer == nil || prob2Bool(math.Tanh(float64(level))) == 0 {
+		return
+	}
+	layer.Add(c.Loc, Params.PheromoneEmitAmount)
+}
+
+func sensePheromone(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	layer := pheromoneLayer(g)
+	if layer == nil {
+		return 0
+	}
+	return layer.At(c.Loc)
+}
+
+// Gradient sensors are 0.5 when the scent is level, above when it's stronger ahead (or to the right).
+func sensePheromoneForward(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	layer := pheromoneLayer(g)
+	if layer == nil {
+		return 0.5
+	}
+	return (layer.Gradient(c.Loc, c.LastMoveDir) + 1) / 2
+}
+
+func sensePheromoneLR(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	layer := pheromoneLayer(g)
+	if layer == nil {
+		return 0.5
+	}
+	return (layer.Gradient(c.Loc, c.LastMoveDir.Rotate90CW()) + 1) / 2
+}
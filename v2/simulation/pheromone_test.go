@@ -0,0 +1,86 @@
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"testing"
+)
+
+func TestPheromone_DiffusesAndDecays(t *testing.T) {
+	s := &Simulation{Grid: grid.NewGrid(30, 30, 0)}
+	s.Grid.AddLayer("pheromone", 0.5, 0.1)
+	layer := pheromoneLayer(s.Grid)
+
+	c := &Creature{Loc: grid.Coord{X: 5, Y: 5}, LastMoveDir: grid.E}
+	for layer.At(c.Loc) == 0 {
+		emitPheromone(s, c, 100, &Movement{})
+	}
+	s.Grid.UpdateLayers()
+	if layer.At(grid.Coord{X: 6, Y: 5}) == 0 {
+		t.Error("Pheromone should spread to neighbouring cells")
+	}
+	if layer.At(grid.Coord{X: 8, Y: 5}) != 0 {
+		t.Error("Pheromone should only spread one cell per step")
+	}
+
+	// A creature west of the source faces east, up the gradient.
+	sniffer := &Creature{Loc: grid.Coord{X: 4, Y: 5}, LastMoveDir: grid.E}
+	if fwd := sensePheromoneForward(sniffer, s.Grid, nil, 0); fwd <= 0.5 {
+		t.Errorf("PHEROMONE_FORWARD facing the source = %f, want > 0.5", fwd)
+	}
+	if lr := sensePheromoneLR(sniffer, s.Grid, nil, 0); lr != 0.5 {
+		t.Errorf("PHEROMONE_LR with the source straight ahead = %f, want 0.5", lr)
+	}
+
+	for step := 0; step < 200 && layer.Active(); step++ {
+		s.Grid.UpdateLayers()
+	}
+	if layer.Active() || sensePheromone(c, s.Grid, nil, 0) != 0 {
+		t.Error("Pheromone should decay away once no more is emitted")
+	}
+}
+
+func TestPheromone_FollowsParams(t *testing.T) {
+	saved := *Params
+	defer func() { *Params = saved }()
+	s := New()
+	layer := pheromoneLayer(s.Grid)
+	layer.Add(grid.Coord{X: 5, Y: 5}, 1)
+
+	Params.PheromoneDiffusion, Params.PheromoneDecay = 0, 1
+	s.Step()
+	if layer.Diffusion != 0 || layer.Decay != 1 || layer.Active() {
+		t.Errorf("Changed pheromone rates should apply on the next step, layer has diffusion %f, decay %f", layer.Diffusion, layer.Decay)
+	}
+}
+
+func TestPheromone_ClearedByZeroFill(t *testing.T) {
+	g := grid.NewGrid(10, 10, 0)
+	g.AddLayer("pheromone", 0.2, 0.02)
+	g.Layers[0].Add(grid.Coord{X: 1, Y: 1}, 2)
+	if v := g.Layers[0].At(grid.Coord{X: 1, Y: 1}); v != 1 {
+		t.Errorf("Cells should be capped at 1, got %f", v)
+	}
+	g.ZeroFill()
+	if g.Layers[0].Active() || g.Layers[0].At(grid.Coord{X: 1, Y: 1}) != 0 {
+		t.Error("ZeroFill should clear the layers")
+	}
+}
+
+func TestPheromone_BlockedByWalls(t *testing.T) {
+	g := grid.NewGrid(30, 30, 0)
+	g.DrawBox(10, 0, 11, 30)
+	g.AddLayer("pheromone", 0.5, 0.01)
+	layer := g.Layers[0]
+	layer.Add(grid.Coord{X: 9, Y: 5}, 1)
+	for step := 0; step < 20; step++ {
+		g.UpdateLayers()
+	}
+	if layer.At(grid.Coord{X: 8, Y: 5}) == 0 {
+		t.Error("Pheromone should spread away from the wall")
+	}
+	for x := 10; x < 30; x++ {
+		if v := layer.At(grid.Coord{X: x, Y: 5}); v != 0 {
+			t.Fatalf("Pheromone reached %v through the wall: %f", grid.Coord{X: x, Y: 5}, v)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// plasticity.go: In-lifetime Hebbian learning of edge weights, and writing learned weights back into a genome.
+
+package simulation
+
+import (
+	"biogo/v2/utils"
+	"math"
+)
+
+// learn applies a Hebbian update to every edge after a FeedForward: an edge strengthens when its input
+// and its sink's output have the same sign, and weakens when they differ. Weights stay within -1...1.
+func (c *Creature) learn() {
+	n := &c.Nnet
+	for i := range n.Weights {
+		var post float32
+		if i < n.NeuronEdgeCount {
+			post = n.Neurons[n.SinkIDs[i]].Output
+		} else {
+			post = float32(math.Tanh(float64(c.actionLevelsBuf[n.SinkIDs[i]])))
+		}
+		n.Weights[i] = utils.RestrictFloat32(-1, 1, n.Weights[i]+n.LearningRate*c.edgeInputsBuf[i]*post)
+	}
+}
+
+// HeritableGenome is the genome the creature passes on. It is the creature's own genome unless learning is
+// Lamarckian, in which case it's a copy with the learned weights (rounded to a byte) written into the genes.
+func (c *Creature) HeritableGenome() *Genome {
+	if !Params.Plasticity || !Params.Lamarckian {
+		return c.Genome
+	}
+	learned := c.Genome.Copy()
+	for i, index := range c.Nnet.GeneIndexes {
+		learned.Brain[index].Weight = floatAsByte(c.Nnet.Weights[i])
+	}
+	return learned
+}
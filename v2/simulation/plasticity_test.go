@@ -0,0 +1,41 @@
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"testing"
+)
+
+func TestPlasticity_HebbianAndInheritance(t *testing.T) {
+	defer func(p Parameters) { *Params = p }(*Params)
+	Params.Plasticity = true
+	Params.BaseLearningRate = 0.1
+
+	g := &Genome{LearningRate: 255, BrainLength: 1, Brain: []*Gene{
+		{SourceType: SENSOR, SourceID: LOC_X, SinkType: ACTION, SinkID: MOVE_EAST, Weight: 200},
+	}}
+	c := &Creature{Genome: g, Loc: grid.Coord{X: Params.GridWidth / 2}}
+	c.CreateNeuralNet()
+	before := c.Nnet.Weights[0]
+	for step := 0; step < 20; step++ {
+		c.FeedForward(nil, nil, step)
+	}
+	if c.Nnet.Weights[0] <= before {
+		t.Fatalf("Correlated input and output should strengthen the edge, weight went from %f to %f", before, c.Nnet.Weights[0])
+	}
+	if got := c.Nnet.Graph().Edges[0].Weight; got != c.Nnet.Weights[0] {
+		t.Errorf("Exported weight %f should be the learned %f", got, c.Nnet.Weights[0])
+	}
+
+	Params.Lamarckian = false
+	if c.HeritableGenome() != g {
+		t.Error("Baldwinian learning should pass on the unchanged genome")
+	}
+	Params.Lamarckian = true
+	learned := c.HeritableGenome()
+	if learned.Brain[0].Weight <= g.Brain[0].Weight {
+		t.Errorf("Lamarckian learning should pass on the learned weight, got %d from %d", learned.Brain[0].Weight, g.Brain[0].Weight)
+	}
+	if g.Brain[0].Weight != 200 {
+		t.Error("Writing back learned weights should not modify the creature's own genome")
+	}
+}
@@ -2,19 +2,41 @@ package simulation
 
 import (
 	"biogo/v2/grid"
+	"biogo/v2/jaro"
 	"biogo/v2/utils"
 	"math/rand"
+	"sort"
 )
 
+// PopulationConfig describes one of the populations sharing the grid. Zero Size, StartingSize and
+// BaseMutationRate fall back to the global parameters; Challenge has no fallback.
+type PopulationConfig struct {
+	Name             string
+	Size             int // Creatures in each generation after the first
+	StartingSize     int // Creatures in the first generation
+	Challenge        ChallengeType
+	BaseMutationRate float32
+}
+
 type Population struct {
+	Name              string
+	Config            PopulationConfig
+	IDBase            int // Grid value of Creatures[0], creature i is IDBase + i
 	Creatures         []*Creature
 	DeathQueue        []DeathInstruction
 	MoveQueue         []MoveInstruction
 	ReproductionQueue []ReproductionInstruction
+	Kills             int // Creatures this population killed this generation
+	Killed            int // Creatures of this population killed by others this generation
+	Immigrants        int // Genomes that arrived from other islands at the start of this generation
+
+	shared     []*Population   // Every population on the grid, for CreatureAt
+	speciesIDs map[*Genome]int // SpeciesID's grouping, cleared when a genome is replaced
 }
 
 type DeathInstruction struct {
 	Creature *Creature
+	Killer   *Creature // nil if the creature didn't die to another
 }
 
 type ReproductionInstruction struct {
@@ -26,16 +48,50 @@ type MoveInstruction struct {
 	Loc      grid.Coord
 }
 
-func NewPopulation() *Population {
-	creatures := make([]*Creature, Params.StartingPopulation)
+type GenomeCount struct {
+	Genome *Genome
+	Count  int
+}
+
+func NewPopulation(config PopulationConfig, idBase int) *Population {
 	return &Population{
-		Creatures:         creatures,
+		Name:              config.Name,
+		Config:            config,
+		IDBase:            idBase,
+		Creatures:         []*Creature{},
 		DeathQueue:        []DeathInstruction{},
 		MoveQueue:         []MoveInstruction{},
 		ReproductionQueue: []ReproductionInstruction{},
 	}
 }
 
+// populate places a creature at each location, cycling through genomes, and marks them on the grid.
+func (p *Population) populate(g *grid.Grid, locs []grid.Coord, genomes []*Genome) {
+	p.Creatures = make([]*Creature, len(locs))
+	for i, loc := range locs {
+		c := NewCreature(p.IDBase+i, loc, genomes[i%len(genomes)])
+		c.Population = p
+		p.Creatures[i] = c
+		g.Set(loc, c.Id)
+	}
+}
+
+// replaceGenome swaps creature i for a new creature with genome g, in the same place.
+func (p *Population) replaceGenome(i int, g *Genome) {
+	old := p.Creatures[i]
+	c := NewCreature(old.Id, old.Loc, g)
+	c.Population = p
+	p.Creatures[i] = c
+	p.speciesIDs = nil
+}
+
+// reproduce copies and mutates a parent's genome at the population's mutation rate.
+func (p *Population) reproduce(parent *Genome) *Genome {
+	child := parent.Copy()
+	mutate(child, p.Config.BaseMutationRate)
+	return child
+}
+
 func (p *Population) QueueForMove(creature *Creature, newLoc grid.Coord) {
 	instruction := MoveInstruction{creature, newLoc}
 	p.MoveQueue = append(p.MoveQueue, instruction)
@@ -43,7 +99,7 @@ func (p *Population) QueueForMove(creature *Creature, newLoc grid.Coord) {
 
 func (p *Population) ProcessMoveQueue(g *grid.Grid) {
 	for _, instruction := range p.MoveQueue {
-		if g.IsEmptyAt(instruction.Loc) {
+		if instruction.Creature.Alive && g.IsEmptyAt(instruction.Loc) {
 			g.Set(instruction.Creature.Loc, 0)
 			g.Set(instruction.Loc, instruction.Creature.Id)
 			instruction.Creature.LastMoveDir = grid.GetDirection(instruction.Creature.Loc, instruction.Loc)
@@ -53,6 +109,56 @@ func (p *Population) ProcessMoveQueue(g *grid.Grid) {
 	p.MoveQueue = []MoveInstruction{}
 }
 
+func (p *Population) QueueForDeath(creature, killer *Creature) {
+	p.DeathQueue = append(p.DeathQueue, DeathInstruction{creature, killer})
+}
+
+// ProcessDeathQueue removes the queued creatures from the grid. A creature queued more than once
+// only dies once, and only its first killer is rewarded.
+func (p *Population) ProcessDeathQueue(g *grid.Grid) {
+	for _, instruction := range p.DeathQueue {
+		c := instruction.Creature
+		if !c.Alive {
+			continue
+		}
+		c.Alive = false
+		g.Set(c.Loc, grid.EMPTY)
+		if killer := instruction.Killer; killer != nil {
+			p.Killed++
+			if killer.Population != nil {
+				killer.Population.Kills++
+			}
+			killer.Energy = utils.MinFloat32(killer.Energy+Params.KillEnergyReward, float32(killer.Genome.MaxEnergy))
+		}
+	}
+	p.DeathQueue = []DeathInstruction{}
+}
+
+// CreatureAt returns the creature occupying loc, from any population sharing the grid, or nil if there isn't one.
+func (p *Population) CreatureAt(g *grid.Grid, loc grid.Coord) *Creature {
+	if !g.IsInBounds(loc) || !g.IsOccupiedAt(loc) {
+		return nil
+	}
+	id := g.At(loc)
+	if p.shared == nil {
+		return p.creatureWithID(id)
+	}
+	for _, other := range p.shared {
+		if c := other.creatureWithID(id); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+func (p *Population) creatureWithID(id int) *Creature {
+	i := id - p.IDBase
+	if i < 0 || i >= len(p.Creatures) {
+		return nil
+	}
+	return p.Creatures[i]
+}
+
 // Random sample of population and compare genetics
 func (p *Population) GeneticDiversity() float32 {
 	if len(p.Creatures) < 2 {
@@ -71,7 +177,111 @@ func (p *Population) GeneticDiversity() float32 {
 		c1 := p.Creatures[i1]
 		c2 := p.Creatures[i2]
 		genomeSimilarityTotal += 1 - GenomeSimilarity(*c1.Genome, *c2.Genome)
-		sampleSize--
+		count--
 	}
 	return genomeSimilarityTotal / float32(sampleSize)
 }
+
+// speciesSampleSize caps the creatures Species groups, as it may compare every pair of them.
+const speciesSampleSize = 100
+
+// Species estimates how many species the population holds. A random sample of its creatures is
+// grouped greedily, each joining the first species whose founder's genome it's at least
+// SexualReproductionSimilarityMin similar to, or else founding a new one.
+func (p *Population) Species() int {
+	sample := p.Creatures
+	if len(sample) > speciesSampleSize {
+		sample = make([]*Creature, speciesSampleSize)
+		for i, j := range rand.Perm(len(p.Creatures))[:speciesSampleSize] {
+			sample[i] = p.Creatures[j]
+		}
+	}
+	founders := []string{}
+	seen := map[string]bool{}
+	for _, c := range sample {
+		genome := c.Genome.String()
+		if seen[genome] {
+			continue
+		}
+		seen[genome] = true
+		found := false
+		for _, founder := range founders {
+			if jaro.JaroWinklerSimilarity(genome, founder) >= Params.SexualReproductionSimilarityMin {
+				found = true
+				break
+			}
+		}
+		if !found {
+			founders = append(founders, genome)
+		}
+	}
+	return len(founders)
+}
+
+// MaxSpeciesIDs caps the species SpeciesID tells apart.
+const MaxSpeciesIDs = 24
+
+// SpeciesID groups every creature's genome into species the way Species does, taking the most common
+// genomes first, and returns the creature's species numbered from 0 in the order they were founded.
+// Genomes unlike the first MaxSpeciesIDs founders are -1. The grouping is kept for the generation.
+func (p *Population) SpeciesID(c *Creature) int {
+	if p.speciesIDs == nil {
+		p.speciesIDs = map[*Genome]int{}
+		byGenome := map[string]int{}
+		founders := []string{}
+		for _, gc := range p.MostCommonGenomes(len(p.Creatures)) {
+			genome := gc.Genome.String()
+			id := -1
+			for i, founder := range founders {
+				if jaro.JaroWinklerSimilarity(genome, founder) >= Params.SexualReproductionSimilarityMin {
+					id = i
+					break
+				}
+			}
+			if id == -1 && len(founders) < MaxSpeciesIDs {
+				id = len(founders)
+				founders = append(founders, genome)
+			}
+			byGenome[genome] = id
+		}
+		for _, creature := range p.Creatures {
+			p.speciesIDs[creature.Genome] = byGenome[creature.Genome.String()]
+		}
+	}
+	if id, ok := p.speciesIDs[c.Genome]; ok {
+		return id
+	}
+	return -1
+}
+
+// MeanBrainSize is the average number of connections in the creatures' pruned brains.
+func (p *Population) MeanBrainSize() float32 {
+	if len(p.Creatures) == 0 {
+		return 0
+	}
+	total := 0
+	for _, c := range p.Creatures {
+		total += len(c.Nnet.Edges)
+	}
+	return float32(total) / float32(len(p.Creatures))
+}
+
+// MostCommonGenomes returns up to n of the population's distinct genomes, most common first.
+func (p *Population) MostCommonGenomes(n int) []GenomeCount {
+	counts := []GenomeCount{}
+	index := map[string]int{}
+	for _, creature := range p.Creatures {
+		key := creature.Genome.String()
+		if i, ok := index[key]; ok {
+			counts[i].Count++
+		} else {
+			index[key] = len(counts)
+			counts = append(counts, GenomeCount{Genome: creature.Genome, Count: 1})
+		}
+	}
+	sort.SliceStable(counts, func(i, j int) bool { return counts[i].Count > counts[j].Count })
+	if n < len(counts) {
+		counts = counts[:n]
+	}
+	return counts
+}
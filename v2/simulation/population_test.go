@@ -0,0 +1,118 @@
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"testing"
+)
+
+func TestPopulations_ShareGrid(t *testing.T) {
+	defer func(p []PopulationConfig) { Params.Populations = p }(Params.Populations)
+	Params.Populations = []PopulationConfig{
+		{Name: "predator", Size: 20, StartingSize: 30, Challenge: AllSurvive, BaseMutationRate: 0.01},
+		{Name: "prey", Size: 50, Challenge: AllSurvive},
+	}
+
+	sim := New()
+	if len(sim.Populations) != 2 || len(sim.Populations[0].Creatures) != 30 || len(sim.Populations[1].Creatures) != 50 {
+		t.Fatalf("Expected populations of 30 and 50 creatures")
+	}
+	for _, c := range sim.Creatures() {
+		if sim.CreatureAt(c.Loc) != c {
+			t.Fatalf("Creature %d of %s isn't on the grid at %v", c.Id, c.Population.Name, c.Loc)
+		}
+	}
+
+	sim.InitializeNewGeneration()
+	if len(sim.Populations[0].Creatures) != 20 || sim.Populations[1].IDBase != grid.RESERVED_CELL_TYPES+20 {
+		t.Errorf("The predators should breed 20 creatures, with the prey's IDs following on")
+	}
+	if len(sim.Stats.History) != 2 || sim.Stats.History[0].Population != "predator" || sim.Stats.History[1].Population != "prey" {
+		t.Errorf("Expected a stats row per population, got %+v", sim.Stats.History)
+	}
+	for _, c := range sim.Creatures() {
+		if sim.CreatureAt(c.Loc) != c {
+			t.Fatalf("Creature %d of %s isn't on the grid at %v after breeding", c.Id, c.Population.Name, c.Loc)
+		}
+	}
+}
+
+func TestSenseFriendAndFoe(t *testing.T) {
+	s := duelSim()
+	predator, prey := s.Populations[0].Creatures[0], s.Populations[1].Creatures[0]
+	predator.Genome.SightDistance = 4
+
+	if foe := senseFoeForward(predator, s.Grid, predator.Population, 0); foe != 1 {
+		t.Errorf("FOE_FORWARD with prey adjacent = %f, want 1", foe)
+	}
+	if friend := senseFriendForward(predator, s.Grid, predator.Population, 0); friend != 0 {
+		t.Errorf("FRIEND_FORWARD with prey adjacent = %f, want 0", friend)
+	}
+
+	// Swap the prey for a second predator two cells further on
+	s.Grid.Set(prey.Loc, grid.EMPTY)
+	s.Populations[1].Creatures = nil
+	friendLoc := grid.Coord{X: predator.Loc.X + 3, Y: predator.Loc.Y}
+	s.Populations[0].populate(s.Grid, []grid.Coord{predator.Loc, friendLoc}, []*Genome{predator.Genome})
+	predator = s.Populations[0].Creatures[0]
+	predator.LastMoveDir = grid.E
+	if friend := senseFriendForward(predator, s.Grid, predator.Population, 0); friend != 0.5 {
+		t.Errorf("FRIEND_FORWARD with a friend 3 cells away and sight 4 = %f, want 0.5", friend)
+	}
+	if foe := senseFoeForward(predator, s.Grid, predator.Population, 0); foe != 0 {
+		t.Errorf("FOE_FORWARD with only a friend in sight = %f, want 0", foe)
+	}
+}
+
+func TestPopulation_DiversityAndSpecies(t *testing.T) {
+	defer smallWorld()()
+	sim := New()
+	pop := sim.Populations[0]
+	if id := pop.SpeciesID(pop.Creatures[0]); id != 0 {
+		t.Errorf("The first creature's genome founds species %d, want 0", id)
+	}
+	if a, b := pop.Creatures[0].Genome.Lineage, pop.Creatures[1].Genome.Lineage; a == 0 || a == b {
+		t.Errorf("Random genomes should start their own lineages, got %d and %d", a, b)
+	}
+	if d := pop.GeneticDiversity(); d <= 0 || d > 1 {
+		t.Errorf("Diversity of random genomes = %f, want between 0 and 1", d)
+	}
+	if n := pop.Species(); n < 2 {
+		t.Errorf("Random genomes should form several species, got %d", n)
+	}
+
+	// A population of clones is one species with no diversity
+	for i := range pop.Creatures {
+		pop.replaceGenome(i, pop.Creatures[0].Genome.Copy())
+	}
+	if d, n := pop.GeneticDiversity(), pop.Species(); d != 0 || n != 1 {
+		t.Errorf("Clones have diversity %f and %d species, want 0 and 1", d, n)
+	}
+	for _, c := range pop.Creatures {
+		if id := pop.SpeciesID(c); id != 0 {
+			t.Fatalf("Clone %d is species %d, want 0", c.Id, id)
+		}
+	}
+	if got, want := pop.MeanBrainSize(), float32(len(pop.Creatures[0].Nnet.Edges)); got != want {
+		t.Errorf("MeanBrainSize of clones = %f, want %f", got, want)
+	}
+
+	sim.Stats.Diversity = true
+	sim.RunGeneration()
+	if st := sim.Stats.History[0]; st.Species != 1 || st.Diversity != 0 || st.BrainSize == 0 && len(pop.Creatures[0].Nnet.Edges) > 0 {
+		t.Errorf("Stats should record the clones' species, diversity and brain size, got %+v", st)
+	}
+}
+
+func TestStats_DiversityOnlyWhenMeasured(t *testing.T) {
+	defer smallWorld()()
+	sim := New()
+	sim.RunGeneration()
+	if st := sim.Stats.History[0]; st.Species != 0 || st.Diversity != 0 {
+		t.Errorf("Diversity and species shouldn't be measured unless asked for, got %+v", st)
+	}
+	sim.Stats.Diversity = true
+	sim.RunGeneration()
+	if st := sim.Stats.History[1]; st.Species == 0 {
+		t.Errorf("Species should be measured once asked for, got %+v", st)
+	}
+}
@@ -0,0 +1,84 @@
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// duelSim has a predator and a prey side by side, the predator facing the prey.
+func duelSim() *Simulation {
+	s := &Simulation{Grid: grid.NewGrid(20, 20, 0)}
+	locs := []grid.Coord{{X: 2, Y: 2}, {X: 3, Y: 2}}
+	for i, name := range []string{"predator", "prey"} {
+		pop := NewPopulation(PopulationConfig{Name: name}, grid.RESERVED_CELL_TYPES+i)
+		pop.populate(s.Grid, locs[i:i+1], []*Genome{MakeRandomGenome()})
+		pop.Creatures[0].LastMoveDir = grid.E
+		s.Populations = append(s.Populations, pop)
+	}
+	for _, pop := range s.Populations {
+		pop.shared = s.Populations
+	}
+	return s
+}
+
+func TestKillForward(t *testing.T) {
+	defer func(p float32) { Params.KillProbability = p }(Params.KillProbability)
+	Params.KillProbability = 1
+
+	s := duelSim()
+	predators, prey := s.Populations[0], s.Populations[1]
+	killer, victim := predators.Creatures[0], prey.Creatures[0]
+	if predators.CreatureAt(s.Grid, victim.Loc) != victim {
+		t.Fatal("CreatureAt should find creatures of other populations on the grid")
+	}
+	energy := killer.Energy
+
+	killForward(s, killer, 100, &Movement{})
+	killForward(s, killer, 100, &Movement{})
+	prey.QueueForMove(victim, grid.Coord{X: 4, Y: 2})
+	prey.ProcessDeathQueue(s.Grid)
+	prey.ProcessMoveQueue(s.Grid)
+
+	if victim.Alive {
+		t.Fatal("The creature in front should have been killed")
+	}
+	if !s.Grid.IsEmptyAt(grid.Coord{X: 3, Y: 2}) || !s.Grid.IsEmptyAt(grid.Coord{X: 4, Y: 2}) {
+		t.Error("A dead creature should be removed from the grid and not move")
+	}
+	if predators.Kills != 1 || prey.Killed != 1 {
+		t.Errorf("Kills = %d and Killed = %d, want 1 each for a creature attacked twice", predators.Kills, prey.Killed)
+	}
+	if killer.Energy <= energy && energy < float32(killer.Genome.MaxEnergy) {
+		t.Errorf("The killer should be rewarded, energy went from %f to %f", energy, killer.Energy)
+	}
+
+	killForward(s, killer, 100, &Movement{})
+	if len(prey.DeathQueue) != 0 {
+		t.Error("There's nothing left in front to kill")
+	}
+}
+
+func TestInitializeNewGeneration_GridMatchesCreatures(t *testing.T) {
+	sim := New()
+	var out bytes.Buffer
+	if err := sim.Stats.WriteCSV(&out); err != nil {
+		t.Fatal(err)
+	}
+	sim.Populations[0].Kills = 3
+	sim.InitializeNewGeneration()
+
+	for _, c := range sim.Populations[0].Creatures {
+		if sim.Populations[0].CreatureAt(sim.Grid, c.Loc) != c {
+			t.Fatalf("Creature %d isn't on the grid at %v in the new generation", c.Id, c.Loc)
+		}
+	}
+	if len(sim.Stats.History) != 1 || sim.Stats.History[0].Kills != 3 {
+		t.Errorf("Expected one generation recorded with 3 kills, got %+v", sim.Stats.History)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "1,default,") || strings.Join(strings.Split(lines[1], ",")[5:8], ",") != "3,0,0" {
+		t.Errorf("Unexpected stats CSV:\n%s", out.String())
+	}
+}
@@ -0,0 +1,166 @@
+// registry.go: Registry of named sensors and actions, their behaviour, and which of them creatures can evolve to use.
+
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"fmt"
+	"strings"
+)
+
+// SensorFunc reads a sensor for a creature. Outputs are clamped to 0...1 by GetSensor.
+type SensorFunc func(c *Creature, g *grid.Grid, p *Population, simStep int) float32
+
+// ActionFunc applies an action level to a creature. Movement actions add to move rather than
+// moving the creature themselves, as all the movement is combined in ExecuteActions.
+type ActionFunc func(s *Simulation, c *Creature, level float32, move *Movement)
+
+// Movement accumulates the movement actions for a creature during ExecuteActions.
+type Movement struct {
+	X, Y float32
+}
+
+type Sensor struct {
+	Name    string
+	Code    string // Short code, for places where the full name doesn't fit
+	Enabled bool
+	Eval    SensorFunc
+
+	enabledByDefault bool // Enabled as declared, which ConfigureRegistry starts from
+}
+
+type Action struct {
+	Name    string
+	Code    string // Short code, for places where the full name doesn't fit
+	Enabled bool
+	Execute ActionFunc
+
+	enabledByDefault bool
+}
+
+func init() {
+	for _, s := range sensors {
+		s.enabledByDefault = s.Enabled
+	}
+	for _, a := range actions {
+		a.enabledByDefault = a.Enabled
+	}
+}
+
+// RegisterSensor adds a sensor to the registry and returns its ID.
+func RegisterSensor(s Sensor) byte {
+	if len(sensors) > 255 {
+		panic("Too many sensors registered")
+	}
+	s.enabledByDefault = s.Enabled
+	sensors = append(sensors, &s)
+	return byte(len(sensors) - 1)
+}
+
+// RegisterAction adds an action to the registry and returns its ID.
+func RegisterAction(a Action) byte {
+	if len(actions) > 255 {
+		panic("Too many actions registered")
+	}
+	a.enabledByDefault = a.Enabled
+	actions = append(actions, &a)
+	return byte(len(actions) - 1)
+}
+
+// Sensors returns every registered sensor, indexed by ID.
+func Sensors() []*Sensor { return sensors }
+
+// Actions returns every registered action, indexed by ID.
+func Actions() []*Action { return actions }
+
+func SensorCount() int { return len(sensors) }
+
+func ActionCount() int { return len(actions) }
+
+// SensorName returns the printable name of a sensor ID.
+func SensorName(id byte) string {
+	if int(id) < len(sensors) {
+		return sensors[id].Name
+	}
+	return fmt.Sprintf("SENSOR_%d", id)
+}
+
+// ActionName returns the printable name of an action ID.
+func ActionName(id byte) string {
+	if int(id) < len(actions) {
+		return actions[id].Name
+	}
+	return fmt.Sprintf("ACTION_%d", id)
+}
+
+func IsSensorEnabled(s byte) bool {
+	return int(s) < len(sensors) && sensors[s].Enabled
+}
+
+func IsActionEnabled(a byte) bool {
+	return int(a) < len(actions) && actions[a].Enabled
+}
+
+// enabledSensorIDs lists the IDs genes can map onto when building a neural net.
+func enabledSensorIDs() []byte {
+	ids := []byte{}
+	for id, s := range sensors {
+		if s.Enabled {
+			ids = append(ids, byte(id))
+		}
+	}
+	return ids
+}
+
+func enabledActionIDs() []byte {
+	ids := []byte{}
+	for id, a := range actions {
+		if a.Enabled {
+			ids = append(ids, byte(id))
+		}
+	}
+	return ids
+}
+
+// ConfigureRegistry applies the Sensors and Actions overrides in p, matched by name or short code.
+// Every sensor and action not overridden goes back to whether it was declared enabled, so one
+// simulation's overrides don't carry into the next.
+func ConfigureRegistry(p *Parameters) error {
+	for _, s := range sensors {
+		s.Enabled = s.enabledByDefault
+	}
+	for _, a := range actions {
+		a.Enabled = a.enabledByDefault
+	}
+	for key, enabled := range p.Sensors {
+		found := false
+		for _, s := range sensors {
+			if strings.EqualFold(s.Name, key) || strings.EqualFold(s.Code, key) {
+				s.Enabled = enabled
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown sensor %q", key)
+		}
+	}
+	for key, enabled := range p.Actions {
+		found := false
+		for _, a := range actions {
+			if strings.EqualFold(a.Name, key) || strings.EqualFold(a.Code, key) {
+				a.Enabled = enabled
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown action %q", key)
+		}
+	}
+	if len(enabledSensorIDs()) == 0 {
+		return fmt.Errorf("at least one sensor must be enabled")
+	}
+	if len(enabledActionIDs()) == 0 {
+		return fmt.Errorf("at least one action must be enabled")
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"math"
+	"testing"
+)
+
+// withSensors overrides Params.Sensors for the next New, and returns a function putting them and
+// the registry back.
+func withSensors(overrides map[string]bool) func() {
+	saved := Params.Sensors
+	Params.Sensors = overrides
+	return func() {
+		Params.Sensors = saved
+		if err := ConfigureRegistry(Params); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestConfigureRegistry_DisabledSensorsAreNotWired(t *testing.T) {
+	defer ConfigureRegistry(Params)
+
+	err := ConfigureRegistry(&Parameters{Sensors: map[string]bool{"LOC_X": false, "rnd": false}})
+	if err != nil {
+		t.Fatalf("ConfigureRegistry returned error: %v", err)
+	}
+	if IsSensorEnabled(LOC_X) || IsSensorEnabled(RANDOM) {
+		t.Fatal("LOC_X and RANDOM should be disabled by name and by code")
+	}
+	for id := 0; id < 256; id++ {
+		gene := &Gene{SourceType: SENSOR, SourceID: byte(id), SinkType: ACTION, SinkID: byte(id)}
+		converted := convertGenesToNeuronIDs([]*Gene{gene}, 0)[0]
+		if converted.SourceID == LOC_X || converted.SourceID == RANDOM {
+			t.Fatalf("Gene with source %d was wired to disabled sensor %s", id, SensorName(converted.SourceID))
+		}
+	}
+}
+
+func TestConfigureRegistry_Errors(t *testing.T) {
+	if err := ConfigureRegistry(&Parameters{Actions: map[string]bool{"NOT_AN_ACTION": true}}); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+
+	all := map[string]bool{}
+	for _, a := range actions {
+		all[a.Name] = false
+	}
+	defer ConfigureRegistry(Params)
+	if err := ConfigureRegistry(&Parameters{Actions: all}); err == nil {
+		t.Error("Expected an error when every action is disabled")
+	}
+}
+
+func TestConfigureRegistry_ResetsEarlierOverrides(t *testing.T) {
+	restore := withSensors(map[string]bool{"LOC_X": false})
+	New()
+	if IsSensorEnabled(LOC_X) {
+		t.Fatal("LOC_X should be disabled by the override")
+	}
+	restore()
+	New()
+	if !IsSensorEnabled(LOC_X) || !IsSensorEnabled(RANDOM) {
+		t.Error("A later simulation without the override should have LOC_X back")
+	}
+
+	id := RegisterSensor(Sensor{Name: "OFF_BY_DEFAULT"})
+	defer func() { sensors = sensors[:len(sensors)-1] }()
+	if err := ConfigureRegistry(&Parameters{Sensors: map[string]bool{"OFF_BY_DEFAULT": true}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureRegistry(&Parameters{}); err != nil {
+		t.Fatal(err)
+	}
+	if IsSensorEnabled(id) {
+		t.Error("A sensor registered disabled should go back to disabled")
+	}
+}
+
+func TestRegisterSensor(t *testing.T) {
+	defer func() { sensors = sensors[:len(sensors)-1] }()
+
+	id := RegisterSensor(Sensor{Name: "ALWAYS_ON", Code: "On", Enabled: true, Eval: func(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+		return 2
+	}})
+	if SensorName(id) != "ALWAYS_ON" {
+		t.Errorf("SensorName(%d) = %s, want ALWAYS_ON", id, SensorName(id))
+	}
+	c := &Creature{}
+	if got := c.GetSensor(id, nil, nil, 0); got != 1 {
+		t.Errorf("GetSensor should clamp registered sensors to 1, got %f", got)
+	}
+}
+
+// These pin the values fixed when the sensors and actions moved into the registry: AGE,
+// BOUNDARY_DIST and OSC1 used integer division and were stuck at 0 or 1, MOVE_Y moved along X,
+// MOVE_SOUTH moved north, and an unknown sensor read a random value.
+func TestRegistry_CorrectedSensors(t *testing.T) {
+	defer smallWorld()()
+	c := &Creature{Age: 10, Loc: grid.Coord{X: 10, Y: 20}, Genome: &Genome{OscPeriod: 4}}
+
+	if got := senseAge(c, nil, nil, 0); got != 0.5 {
+		t.Errorf("AGE at half of MaxAge = %f, want 0.5", got)
+	}
+	// 10 cells from the left edge, out of at most 29 on a 60x40 grid
+	if got, want := senseBoundaryDist(c, nil, nil, 0), float32(10)/29; got != want {
+		t.Errorf("BOUNDARY_DIST = %f, want %f", got, want)
+	}
+	for step, want := range map[int]float32{0: 1, 1: 0.5, 2: 0, 4: 1} {
+		if got := senseOscillator(c, nil, nil, step); math.Abs(float64(got-want)) > 1e-6 {
+			t.Errorf("OSC1 with period 4 at step %d = %f, want %f", step, got, want)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if got := c.GetSensor(byte(SensorCount()+i), nil, nil, 0); got != 0 {
+			t.Fatalf("GetSensor of an unknown sensor = %f, want 0", got)
+		}
+	}
+}
+
+func TestRegistry_CorrectedMoves(t *testing.T) {
+	c := &Creature{}
+	move := Movement{}
+	moveY(nil, c, 0.5, &move)
+	if move != (Movement{X: 0, Y: 0.5}) {
+		t.Errorf("MOVE_Y 0.5 gave %+v, want it along Y", move)
+	}
+	move = Movement{}
+	moveSouth(nil, c, 0.5, &move)
+	if move.X != 0 || move.Y != float32(grid.S.Y)*0.5 {
+		t.Errorf("MOVE_SOUTH 0.5 gave %+v, want it towards %v", move, grid.S)
+	}
+	move = Movement{}
+	moveNorth(nil, c, 0.5, &move)
+	moveSouth(nil, c, 0.5, &move)
+	if move != (Movement{}) {
+		t.Errorf("MOVE_NORTH and MOVE_SOUTH at the same level should cancel, got %+v", move)
+	}
+}
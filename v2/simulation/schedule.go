@@ -0,0 +1,180 @@
+// schedule.go: Scheduled changes to the challenge, map and parameters, triggered by generation or by sustained survival.
+
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"fmt"
+	"strconv"
+)
+
+// ScheduleEvent changes the run between generations. It fires once, at the end of the first
+// generation where all of its triggers hold, and its changes apply from the next generation on.
+type ScheduleEvent struct {
+	Name string
+
+	// Triggers, any left at zero are ignored
+	AtGeneration   int     // At least this many generations have finished
+	SurvivalAbove  float32 // Survival was at least this...
+	ForGenerations int     // ...for this many generations in a row (1 if 0), counted after After fired
+	After          string  // The named event has fired
+
+	// Set changes parameters by name. Challenge and BaseMutationRate change the populations' own
+	// settings (only Population's, if given), Map changes the grid's layout, MapFile loads a new map
+	// file, and anything else is set on Params. Challenges and maps can be given by name or number.
+	Set        map[string]string
+	Population string
+}
+
+// scheduleState tracks each event of the simulation's schedule.
+type scheduleState struct {
+	events []ScheduleEvent
+	fired  []bool
+	streak []int // Generations in a row the event's survival trigger has held
+}
+
+// newScheduleState checks the events against each other and the configured populations.
+func newScheduleState(events []ScheduleEvent, populations []PopulationConfig) (*scheduleState, error) {
+	names := map[string]bool{}
+	for _, e := range events {
+		names[e.Name] = true
+	}
+	populationNames := map[string]bool{}
+	for _, config := range populations {
+		populationNames[config.Name] = true
+	}
+	for _, e := range events {
+		if e.After != "" && !names[e.After] {
+			return nil, fmt.Errorf("schedule event %q: After names unknown event %q", e.Name, e.After)
+		}
+		if e.Population != "" && !populationNames[e.Population] {
+			return nil, fmt.Errorf("schedule event %q: unknown population %q", e.Name, e.Population)
+		}
+		for key, value := range e.Set {
+			if err := checkScheduleSet(key, value); err != nil {
+				return nil, fmt.Errorf("schedule event %q: %w", e.Name, err)
+			}
+		}
+	}
+	return &scheduleState{
+		events: events,
+		fired:  make([]bool, len(events)),
+		streak: make([]int, len(events)),
+	}, nil
+}
+
+func checkScheduleSet(key, value string) error {
+	switch key {
+	case "Challenge":
+		_, err := ParseChallenge(value)
+		return err
+	case "Map":
+		_, err := grid.ParseMapType(value)
+		return err
+	case "BaseMutationRate":
+		_, err := strconv.ParseFloat(value, 32)
+		return err
+	case "MapFile":
+		_, err := grid.LoadMap(value)
+		return err
+	default:
+		p := *Params
+		return p.Set(key, value)
+	}
+}
+
+func (st *scheduleState) hasFired(name string) bool {
+	for i, e := range st.events {
+		if e.Name == name && st.fired[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// due updates the triggers with the generation that just finished, and returns the events that fire.
+func (st *scheduleState) due(generation int, survival map[string]float32) []ScheduleEvent {
+	due := []int{}
+	for i, e := range st.events {
+		if st.fired[i] || (e.After != "" && !st.hasFired(e.After)) {
+			continue
+		}
+		ready := generation >= e.AtGeneration
+		if e.SurvivalAbove > 0 {
+			if survival[e.Population] >= e.SurvivalAbove {
+				st.streak[i]++
+			} else {
+				st.streak[i] = 0
+			}
+			ready = ready && st.streak[i] >= max(e.ForGenerations, 1)
+		}
+		if ready {
+			due = append(due, i)
+		}
+	}
+	// Mark them fired afterwards, so an event's After waits for the next generation
+	events := []ScheduleEvent{}
+	for _, i := range due {
+		st.fired[i] = true
+		events = append(events, st.events[i])
+	}
+	return events
+}
+
+// applySchedule fires any events due after the generation that just finished. configs are the
+// populations' settings for the next generation. survival is keyed by population name, with ""
+// for all populations together.
+func (s *Simulation) applySchedule(configs []PopulationConfig, survival map[string]float32) {
+	if s.schedule == nil {
+		return
+	}
+	for _, e := range s.schedule.due(s.Generation, survival) {
+		fmt.Printf("Generation: %d\tSchedule: %s\n", s.Generation, e.Name)
+		for key, value := range e.Set {
+			switch key {
+			case "Challenge":
+				challenge, _ := ParseChallenge(value)
+				for i := range configs {
+					if e.Population == "" || configs[i].Name == e.Population {
+						configs[i].Challenge = challenge
+					}
+				}
+			case "BaseMutationRate":
+				rate, _ := strconv.ParseFloat(value, 32)
+				for i := range configs {
+					if e.Population == "" || configs[i].Name == e.Population {
+						configs[i].BaseMutationRate = float32(rate)
+					}
+				}
+			case "Map":
+				s.Grid.Type, _ = grid.ParseMapType(value)
+			case "MapFile":
+				m, err := grid.LoadMap(value)
+				if err == nil {
+					err = s.Grid.ApplyMap(m)
+				}
+				if err != nil {
+					panic(err)
+				}
+				Params.MapFile = value
+			default:
+				Params.Set(key, value)
+			}
+		}
+	}
+}
+
+// checkIslandSchedule rejects schedule events that would set Params. Every island shares Params,
+// so one island's event would change the others' runs, and race with their generations.
+func checkIslandSchedule(events []ScheduleEvent) error {
+	for _, e := range events {
+		for key := range e.Set {
+			switch key {
+			case "Challenge", "BaseMutationRate", "Map":
+			default:
+				return fmt.Errorf("schedule event %q sets %s, which every island shares; islands can only schedule Challenge, BaseMutationRate and Map", e.Name, key)
+			}
+		}
+	}
+	return nil
+}
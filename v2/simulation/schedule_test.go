@@ -0,0 +1,59 @@
+package simulation
+
+import (
+	"biogo/v2/grid"
+	"testing"
+)
+
+func TestSchedule_Curriculum(t *testing.T) {
+	defer smallWorld()()
+	Params.Schedule = []ScheduleEvent{
+		{Name: "harder", SurvivalAbove: 0.9, ForGenerations: 2, Set: map[string]string{"Challenge": "LeftSurvive"}},
+		{Name: "open up", After: "harder", AtGeneration: 4, Set: map[string]string{"Map": "NO_WALLS", "BaseMutationRate": "0.5", "KillProbability": "0.25"}},
+	}
+
+	sim := New()
+	pop := func() *Population { return sim.Populations[0] }
+	sim.RunGeneration()
+	if pop().Config.Challenge != AllSurvive {
+		t.Fatalf("After 1 generation at 100%% survival the challenge should still be AllSurvive, got %s", pop().Config.Challenge)
+	}
+	sim.RunGeneration()
+	if pop().Config.Challenge != LeftSurvive {
+		t.Fatalf("After 2 generations at 100%% survival the challenge should be LeftSurvive, got %s", pop().Config.Challenge)
+	}
+	if len(sim.Grid.WallLocations) == 0 {
+		t.Fatal("The map shouldn't change before generation 4")
+	}
+
+	for sim.Generation < 4 {
+		sim.RunGeneration()
+	}
+	if len(sim.Grid.WallLocations) != 0 || sim.Grid.Type != grid.NO_WALLS {
+		t.Error("The walls should be gone from generation 4")
+	}
+	if pop().Config.BaseMutationRate != 0.5 || Params.KillProbability != 0.25 {
+		t.Errorf("Expected the mutation rate and kill probability to change, got %f and %f", pop().Config.BaseMutationRate, Params.KillProbability)
+	}
+	if pop().Config.Challenge != LeftSurvive {
+		t.Error("Later events shouldn't undo earlier ones")
+	}
+}
+
+func TestSchedule_Invalid(t *testing.T) {
+	cases := [][]ScheduleEvent{
+		{{Name: "a", Set: map[string]string{"Challenge": "Sideways"}}},
+		{{Name: "a", Set: map[string]string{"NoSuchParameter": "1"}}},
+		{{Name: "a", After: "b"}},
+		{{Name: "a", SurvivalAbove: 0.5, Population: "defualt"}},
+	}
+	populations := Params.PopulationConfigs()
+	if _, err := newScheduleState([]ScheduleEvent{{Name: "a", SurvivalAbove: 0.5, Population: populations[0].Name}}, populations); err != nil {
+		t.Errorf("A configured population should be accepted, got %v", err)
+	}
+	for _, events := range cases {
+		if _, err := newScheduleState(events, populations); err == nil {
+			t.Errorf("Expected an error for schedule %+v", events)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package simulation
 import (
 	"biogo/v2/grid"
 	"biogo/v2/utils"
-	"fmt"
 	"math"
 	"math/rand"
 )
@@ -25,114 +24,184 @@ const (
 	SIGHT_POPULATION_FORWARD
 	GENETIC_SIM_FORWARD
 	RANDOM
-
-	SENSOR_COUNT
+	PHEROMONE
+	PHEROMONE_FORWARD
+	PHEROMONE_LR
+	FRIEND_FORWARD
+	FOE_FORWARD
+	BARRIER_FORWARD
+	BARRIER_DIST
+	BARRIER_LR
+	GOAL_DIST
+	GOAL_FORWARD
+	GOAL_LR
+
+	SENSOR_COUNT // Built in sensors, more can be added with RegisterSensor
 )
 
-func (c Creature) GetSensor(sensorID byte, g *grid.Grid, p *Population, simStep int) float32 {
-	var output float32
-	switch sensorID {
-	case AGE:
-		output = float32(c.Age / Params.MaxAge)
-
-	case ENERGY:
-		output = float32(c.Energy / float32(c.Genome.MaxEnergy))
-
-	case BOUNDARY_DIST:
-		distX := utils.Min(c.Loc.X, Params.GridWidth-c.Loc.X-1)
-		distY := utils.Min(c.Loc.Y, Params.GridHeight-c.Loc.Y-1)
-		closest := utils.Min(distX, distY)
-		maxPossible := utils.Max(Params.GridWidth/2-1, Params.GridHeight/2-1)
-		output = float32(closest / maxPossible)
-
-	case BOUNDARY_DIST_X:
-		distX := utils.Min(c.Loc.X, Params.GridWidth-c.Loc.X-1)
-		output = float32(distX) / float32(Params.GridWidth/2)
-
-	case BOUNDARY_DIST_Y:
-		distY := utils.Min(c.Loc.Y, Params.GridHeight-c.Loc.Y-1)
-		output = float32(distY) / float32(Params.GridHeight/2)
-
-	case LAST_MOVE_DIR_X:
-		if c.LastMoveDir.X == 0 {
-			output = 0.5
-		} else {
-			if c.LastMoveDir.X == -1 {
-				output = 0
-			} else {
-				output = 1
-			}
-		}
+var sensors = []*Sensor{
+	AGE:                      {Name: "AGE", Code: "Age", Enabled: true, Eval: senseAge},
+	ENERGY:                   {Name: "ENERGY", Code: "En", Enabled: true, Eval: senseEnergy},
+	BOUNDARY_DIST:            {Name: "BOUNDARY_DIST", Code: "ED", Enabled: true, Eval: senseBoundaryDist},
+	BOUNDARY_DIST_X:          {Name: "BOUNDARY_DIST_X", Code: "EDx", Enabled: true, Eval: senseBoundaryDistX},
+	BOUNDARY_DIST_Y:          {Name: "BOUNDARY_DIST_Y", Code: "EDy", Enabled: true, Eval: senseBoundaryDistY},
+	LAST_MOVE_DIR_X:          {Name: "LAST_MOVE_DIR_X", Code: "LMx", Enabled: true, Eval: senseLastMoveDirX},
+	LAST_MOVE_DIR_Y:          {Name: "LAST_MOVE_DIR_Y", Code: "LMy", Enabled: true, Eval: senseLastMoveDirY},
+	LOC_X:                    {Name: "LOC_X", Code: "Lx", Enabled: true, Eval: senseLocX},
+	LOC_Y:                    {Name: "LOC_Y", Code: "Ly", Enabled: true, Eval: senseLocY},
+	OSC1:                     {Name: "OSC1", Code: "Osc", Enabled: true, Eval: senseOscillator},
+	POPULATION_LOCAL_DENSITY: {Name: "POPULATION_LOCAL_DENSITY", Code: "Pop", Enabled: true, Eval: sensePopulationLocalDensity},
+	POPULATION_FORWARD:       {Name: "POPULATION_FORWARD", Code: "Pfd", Enabled: true, Eval: sensePopulationForward},
+	POPULATION_LR:            {Name: "POPULATION_LR", Code: "Plr", Enabled: true, Eval: sensePopulationLR},
+	SIGHT_POPULATION_FORWARD: {Name: "SIGHT_POPULATION_FORWARD", Code: "Sfd", Enabled: true, Eval: calculateSightPopFwd},
+	GENETIC_SIM_FORWARD:      {Name: "GENETIC_SIM_FORWARD", Code: "Gen", Enabled: true, Eval: senseGeneticSimForward},
+	RANDOM:                   {Name: "RANDOM", Code: "Rnd", Enabled: true, Eval: senseRandom},
+	PHEROMONE:                {Name: "PHEROMONE", Code: "Phe", Enabled: false, Eval: sensePheromone},
+	PHEROMONE_FORWARD:        {Name: "PHEROMONE_FORWARD", Code: "Phf", Enabled: false, Eval: sensePheromoneForward},
+	PHEROMONE_LR:             {Name: "PHEROMONE_LR", Code: "Phl", Enabled: false, Eval: sensePheromoneLR},
+	FRIEND_FORWARD:           {Name: "FRIEND_FORWARD", Code: "Frd", Enabled: false, Eval: senseFriendForward},
+	FOE_FORWARD:              {Name: "FOE_FORWARD", Code: "Foe", Enabled: false, Eval: senseFoeForward},
+	BARRIER_FORWARD:          {Name: "BARRIER_FORWARD", Code: "Bfd", Enabled: false, Eval: senseBarrierForward},
+	BARRIER_DIST:             {Name: "BARRIER_DIST", Code: "Bds", Enabled: false, Eval: senseBarrierDist},
+	BARRIER_LR:               {Name: "BARRIER_LR", Code: "Blr", Enabled: false, Eval: senseBarrierLR},
+	GOAL_DIST:                {Name: "GOAL_DIST", Code: "Gds", Enabled: false, Eval: senseGoalDist},
+	GOAL_FORWARD:             {Name: "GOAL_FORWARD", Code: "Gfd", Enabled: false, Eval: senseGoalForward},
+	GOAL_LR:                  {Name: "GOAL_LR", Code: "Glr", Enabled: false, Eval: senseGoalLR},
+}
 
-	case LAST_MOVE_DIR_Y:
-		if c.LastMoveDir.Y == 0 {
-			output = 0.5
-		} else {
-			if c.LastMoveDir.Y == -1 {
-				output = 0
-			} else {
-				output = 1
-			}
-		}
+// GetSensor evaluates a sensor from the registry, clamping the output to 0...1.
+func (c *Creature) GetSensor(sensorID byte, g *grid.Grid, p *Population, simStep int) float32 {
+	if int(sensorID) >= len(sensors) {
+		return 0
+	}
+	output := sensors[sensorID].Eval(c, g, p, simStep)
+	if output < 0 || output > 1 {
+		output = utils.RestrictFloat32(0, 1, output)
+	}
+	return output
+}
 
-	case LOC_X:
-		output = float32(c.Loc.X) / float32(Params.GridWidth-1)
+func senseAge(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return float32(c.Age) / float32(Params.MaxAge)
+}
 
-	case LOC_Y:
-		output = float32(c.Loc.Y) / float32(Params.GridHeight-1)
+func senseEnergy(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return float32(c.Energy / float32(c.Genome.MaxEnergy))
+}
 
-	case OSC1:
-		val := int(c.Genome.OscPeriod)
-		if val == 0 {
-			val += 1
-		}
-		phase := float64(simStep % val / val)
-		factor := math.Cos(phase * 2 * math.Pi)
-		factor += 1
-		factor /= 2
-		// Clip round off error
-		output = utils.RestrictFloat32(0, 1, float32(factor))
-	case POPULATION_LOCAL_DENSITY:
-		output = getLocalPopulationDensity(c.Loc, g)
-
-	case POPULATION_FORWARD:
-		output = getPopulationDensityAlongAxis(c.Loc, g, c.LastMoveDir)
-
-	case POPULATION_LR:
-		output = getPopulationDensityAlongAxis(c.Loc, g, c.LastMoveDir.Rotate90CW())
-
-	case SIGHT_POPULATION_FORWARD:
-		output = calculateSightPopFwd(c, g)
-
-	case GENETIC_SIM_FORWARD:
-		newLoc := grid.Coord{
-			X: c.Loc.X + c.LastMoveDir.X,
-			Y: c.Loc.Y + c.LastMoveDir.Y,
+func senseBoundaryDist(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	distX := utils.Min(c.Loc.X, Params.GridWidth-c.Loc.X-1)
+	distY := utils.Min(c.Loc.Y, Params.GridHeight-c.Loc.Y-1)
+	closest := utils.Min(distX, distY)
+	maxPossible := utils.Max(Params.GridWidth/2-1, Params.GridHeight/2-1)
+	return float32(closest) / float32(maxPossible)
+}
+
+func senseBoundaryDistX(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	distX := utils.Min(c.Loc.X, Params.GridWidth-c.Loc.X-1)
+	return float32(distX) / float32(Params.GridWidth/2)
+}
+
+func senseBoundaryDistY(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	distY := utils.Min(c.Loc.Y, Params.GridHeight-c.Loc.Y-1)
+	return float32(distY) / float32(Params.GridHeight/2)
+}
+
+// dirAsSensor maps a -1, 0, 1 direction component onto 0, 0.5, 1
+func dirAsSensor(d int) float32 {
+	if d == 0 {
+		return 0.5
+	} else if d == -1 {
+		return 0
+	}
+	return 1
+}
+
+func senseLastMoveDirX(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return dirAsSensor(c.LastMoveDir.X)
+}
+
+func senseLastMoveDirY(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return dirAsSensor(c.LastMoveDir.Y)
+}
+
+func senseLocX(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return float32(c.Loc.X) / float32(Params.GridWidth-1)
+}
+
+func senseLocY(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return float32(c.Loc.Y) / float32(Params.GridHeight-1)
+}
+
+func senseOscillator(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	val := int(c.Genome.OscPeriod)
+	if val == 0 {
+		val += 1
+	}
+	phase := float64(simStep%val) / float64(val)
+	factor := math.Cos(phase * 2 * math.Pi)
+	factor += 1
+	factor /= 2
+	// Clip round off error
+	return utils.RestrictFloat32(0, 1, float32(factor))
+}
+
+func sensePopulationLocalDensity(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return getLocalPopulationDensity(c.Loc, g)
+}
+
+func sensePopulationForward(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return getPopulationDensityAlongAxis(c.Loc, g, c.LastMoveDir)
+}
+
+func sensePopulationLR(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return getPopulationDensityAlongAxis(c.Loc, g, c.LastMoveDir.Rotate90CW())
+}
+
+func senseGeneticSimForward(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	other := p.CreatureAt(g, c.GetNextLoc(c.LastMoveDir))
+	if other != nil && other.Alive {
+		//TODO: This function performs very poorly, replace
+		return GenomeSimilarity(*c.Genome, *other.Genome)
+	}
+	return 0
+}
+
+func senseFriendForward(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return senseCreatureForward(c, g, p, true)
+}
+
+func senseFoeForward(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return senseCreatureForward(c, g, p, false)
+}
+
+// senseCreatureForward looks ahead up to the creature's sight distance. If the first creature in
+// sight is from its own population (friend) or another (foe), it returns how close that creature is.
+func senseCreatureForward(c *Creature, g *grid.Grid, p *Population, friend bool) float32 {
+	if c.LastMoveDir == grid.CENTER || c.Genome.SightDistance == 0 {
+		return 0
+	}
+	loc := c.Loc
+	for d := 1; d <= int(c.Genome.SightDistance); d++ {
+		loc = grid.Coord{X: loc.X + c.LastMoveDir.X, Y: loc.Y + c.LastMoveDir.Y}
+		if !g.IsInBounds(loc) || g.IsBarrierAt(loc) {
+			return 0
 		}
-		if g.IsInBounds(newLoc) && g.IsOccupiedAt(newLoc) {
-			otherCreatureId := g.Data[newLoc.X][newLoc.Y]
-			if otherCreatureId-grid.RESERVED_CELL_TYPES < 0 || otherCreatureId-grid.RESERVED_CELL_TYPES > len(p.Creatures) {
-				fmt.Printf("\nError: %d with %d reserved\n", otherCreatureId, grid.RESERVED_CELL_TYPES)
-			} else {
-				otherCreature := p.Creatures[otherCreatureId-grid.RESERVED_CELL_TYPES]
-				if otherCreature.Alive {
-					//TODO: This function performs very poorly, replace
-					output = GenomeSimilarity(*c.Genome, *otherCreature.Genome)
-				}
+		if other := p.CreatureAt(g, loc); other != nil {
+			if (other.Population == c.Population) != friend {
+				return 0
 			}
+			return 1 - float32(d-1)/float32(c.Genome.SightDistance)
 		}
-	case RANDOM:
-		fallthrough
-	default:
-		output = rand.Float32()
-	}
-	if output < 0 || output > 1 {
-		output = utils.RestrictFloat32(0, 1, output)
 	}
-	return output
+	return 0
+}
+
+func senseRandom(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
+	return rand.Float32()
 }
 
-func calculateSightPopFwd(c Creature, g *grid.Grid) float32 {
+func calculateSightPopFwd(c *Creature, g *grid.Grid, p *Population, simStep int) float32 {
 	count := 0
 	newLoc := grid.Coord{
 		X: c.Loc.X + c.LastMoveDir.X,
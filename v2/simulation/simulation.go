@@ -10,17 +10,28 @@ import (
 )
 
 type Simulation struct {
+	Name             string // Prefixes the simulation's output when several run at once
 	Grid             *grid.Grid
-	Population       *Population
+	Populations      []*Population
 	Tick             int
-	Generation       int // Might be useless?
-	GeneticDiversity float32
-	Challenge        ChallengeType
+	Generation       int     // Might be useless?
+	GeneticDiversity float32 // Of the last generation, averaged over the populations, if measured
+	Stats            StatsRecorder
+
+	schedule *scheduleState
 }
 
 func New() *Simulation {
-	sim := Simulation{
-		Challenge: Params.Challenge,
+	if err := ConfigureRegistry(Params); err != nil {
+		panic(err)
+	}
+	sim := Simulation{}
+	if len(Params.Schedule) > 0 {
+		schedule, err := newScheduleState(Params.Schedule, Params.PopulationConfigs())
+		if err != nil {
+			panic(err)
+		}
+		sim.schedule = schedule
 	}
 	sim.InitializeGrid()
 	sim.InitializeFirstGeneration()
@@ -29,20 +40,70 @@ func New() *Simulation {
 
 func (s *Simulation) InitializeGrid() {
 	s.Grid = grid.NewGrid(Params.GridWidth, Params.GridHeight, 0)
+	if Params.MapFile != "" {
+		m, err := grid.LoadMap(Params.MapFile)
+		if err != nil {
+			panic(err)
+		}
+		if err := s.Grid.ApplyMap(m); err != nil {
+			panic(err)
+		}
+	}
+	s.Grid.AddLayer("pheromone", Params.PheromoneDiffusion, Params.PheromoneDecay) // PHEROMONE_LAYER
 }
 
 func (s *Simulation) InitializeFirstGeneration() {
-	pop := NewPopulation()
+	configs := Params.PopulationConfigs()
+	sizes := make([]int, len(configs))
+	genomes := make([][]*Genome, len(configs))
+	for i, config := range configs {
+		sizes[i] = config.StartingSize
+		genomes[i] = make([]*Genome, config.StartingSize)
+		for j := range genomes[i] {
+			genomes[i][j] = MakeRandomGenome()
+		}
+	}
+	s.populate(configs, sizes, genomes, "Not enough empty locations for starting population")
+}
+
+// populate replaces the populations with new ones of the given sizes, placed at random empty locations.
+// Each population's IDs follow on from the previous population's.
+func (s *Simulation) populate(configs []PopulationConfig, sizes []int, genomes [][]*Genome, notEnoughRoom string) {
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
 	emptyLocs := s.Grid.ShuffledEmptyLocations()
-	if len(emptyLocs) < Params.StartingPopulation {
-		panic("Not enough empty locations for starting population")
+	if len(emptyLocs) < total {
+		panic(notEnoughRoom)
+	}
+	s.Populations = make([]*Population, len(configs))
+	idBase := grid.RESERVED_CELL_TYPES
+	for i, config := range configs {
+		pop := NewPopulation(config, idBase)
+		pop.populate(s.Grid, emptyLocs[:sizes[i]], genomes[i])
+		pop.shared = s.Populations
+		s.Populations[i] = pop
+		emptyLocs = emptyLocs[sizes[i]:]
+		idBase += sizes[i]
 	}
-	for i := grid.RESERVED_CELL_TYPES; i < Params.StartingPopulation+grid.RESERVED_CELL_TYPES; i++ {
-		loc := emptyLocs[i-grid.RESERVED_CELL_TYPES]
-		pop.Creatures[i-grid.RESERVED_CELL_TYPES] = NewCreature(i, loc, MakeRandomGenome())
-		s.Grid.Set(loc, i)
+}
+
+// Creatures returns the creatures of every population.
+func (s *Simulation) Creatures() []*Creature {
+	all := []*Creature{}
+	for _, pop := range s.Populations {
+		all = append(all, pop.Creatures...)
 	}
-	s.Population = pop
+	return all
+}
+
+// CreatureAt returns the creature occupying loc, or nil if there isn't one.
+func (s *Simulation) CreatureAt(loc grid.Coord) *Creature {
+	if len(s.Populations) == 0 {
+		return nil
+	}
+	return s.Populations[0].CreatureAt(s.Grid, loc)
 }
 
 func (s *Simulation) Update() {
@@ -56,76 +117,129 @@ func (s *Simulation) Update() {
 	}
 }
 
+// RunGeneration steps through the rest of the current generation and starts the next one, without a UI.
+func (s *Simulation) RunGeneration() {
+	for s.Tick < Params.MaxAge {
+		s.Step()
+	}
+	s.InitializeNewGeneration()
+}
+
+// InitializeNewGeneration breeds each population from its own survivors.
 func (s *Simulation) InitializeNewGeneration() {
-	// s.GeneticDiversity = s.Population.GeneticDiversity()
 	s.Generation += 1
 	s.Tick = 0
-	childrenGenomes := []*Genome{}
-	for _, creature := range s.Population.Creatures {
-		if PassedSurvivalCriteria(creature, s) {
-			newGenome := AsexualReproduction(creature.Genome)
-			childrenGenomes = append(childrenGenomes, newGenome)
+	configs := make([]PopulationConfig, len(s.Populations))
+	sizes := make([]int, len(s.Populations))
+	childrenGenomes := make([][]*Genome, len(s.Populations))
+	survival := map[string]float32{}
+	size, survivors := 0, 0
+	diversity := float32(0)
+	for i, pop := range s.Populations {
+		for _, creature := range pop.Creatures {
+			if creature.Alive && PassedSurvivalCriteria(creature, s, pop.Config.Challenge) {
+				childrenGenomes[i] = append(childrenGenomes[i], pop.reproduce(creature.HeritableGenome()))
+			}
 		}
-	}
+		configs[i] = pop.Config
+		sizes[i] = pop.Config.Size
 
-	if len(childrenGenomes) == 0 {
-		panic("The creatures have gone extinct.")
+		stats := GenerationStats{
+			Generation:   s.Generation,
+			Population:   pop.Name,
+			Size:         len(pop.Creatures),
+			Survivors:    len(childrenGenomes[i]),
+			SurvivalRate: float32(len(childrenGenomes[i])) / float32(len(pop.Creatures)),
+			Kills:        pop.Kills,
+			Killed:       pop.Killed,
+			Immigrants:   pop.Immigrants,
+			BrainSize:    pop.MeanBrainSize(),
+		}
+		if s.Stats.measuresDiversity() {
+			stats.Diversity, stats.Species = pop.GeneticDiversity(), pop.Species()
+		}
+		survival[pop.Name] = stats.SurvivalRate
+		diversity += stats.Diversity * float32(stats.Size)
+		size += stats.Size
+		survivors += stats.Survivors
+		if err := s.Stats.Record(stats); err != nil {
+			fmt.Printf("Failed to write stats: %v\n", err)
+		}
+		prefix := ""
+		if s.Name != "" {
+			prefix = s.Name + "\t"
+		}
+		if len(s.Populations) == 1 {
+			fmt.Printf("%sGeneration: %d\t%.2f%% Survived\n", prefix, s.Generation, stats.SurvivalRate*100)
+		} else {
+			fmt.Printf("%sGeneration: %d\t%s\t%.2f%% Survived\n", prefix, s.Generation, pop.Name, stats.SurvivalRate*100)
+		}
 	}
-	survivalPercentage := float64(len(childrenGenomes)) / float64(len(s.Population.Creatures)) * 100
-	fmt.Printf("Generation: %d\t%.2f%% Survived\n", s.Generation, survivalPercentage)
+	survival[""] = float32(survivors) / float32(size)
+	s.GeneticDiversity = diversity / float32(size)
+	s.applySchedule(configs, survival)
 
-	children := []*Creature{}
-	emptyLocs := s.Grid.ShuffledEmptyLocations()
-	if len(emptyLocs) < Params.MaxPopulation {
-		panic("Not enough empty locations for new generation")
-	}
-	for i := grid.RESERVED_CELL_TYPES; i < Params.MaxPopulation+grid.RESERVED_CELL_TYPES; i++ {
-		loc := emptyLocs[i-grid.RESERVED_CELL_TYPES]
-		child := NewCreature(i-grid.RESERVED_CELL_TYPES, loc, childrenGenomes[(i-grid.RESERVED_CELL_TYPES)%len(childrenGenomes)])
-		children = append(children, child)
-		s.Grid.Set(loc, i)
+	for i, pop := range s.Populations {
+		if len(childrenGenomes[i]) == 0 {
+			if len(s.Populations) == 1 {
+				panic("The creatures have gone extinct.")
+			}
+			panic(fmt.Sprintf("The %s population has gone extinct.", pop.Name))
+		}
 	}
 
-	s.Population = &Population{
-		Creatures:  children,
-		DeathQueue: []DeathInstruction{},
-		MoveQueue:  []MoveInstruction{},
-	}
+	// Clear the old generation off the grid before placing the new one
 	s.Grid.ZeroFill()
 	s.Grid.CreateWall()
+	s.populate(configs, sizes, childrenGenomes, "Not enough empty locations for new generation")
 }
 
 func (s *Simulation) Step() {
-	for _, creature := range s.Population.Creatures {
-		if creature.Alive {
-			s.StepCreature(creature)
+	for _, pop := range s.Populations {
+		for _, creature := range pop.Creatures {
+			if creature.Alive {
+				s.StepCreature(creature)
+			}
 		}
 	}
-	s.Population.ProcessMoveQueue(s.Grid)
+	// Deaths first, so the dead don't move
+	for _, pop := range s.Populations {
+		pop.ProcessDeathQueue(s.Grid)
+	}
+	for _, pop := range s.Populations {
+		pop.ProcessMoveQueue(s.Grid)
+	}
+	updatePheromoneRates(s.Grid)
+	s.Grid.UpdateLayers()
 	// TODO()
 	// s.Population.ProcessReproductionQueue(s.Grid)
-	// s.Population.ProcessDeathQueue()
 	s.Tick++
+	s.Grid.UpdateBarriers(s.Tick)
 }
 
 func (s *Simulation) StepCreature(c *Creature) {
 	c.Age++
-	actionLevels := c.FeedForward(s.Grid, s.Population, s.Tick)
+	actionLevels := c.FeedForward(s.Grid, c.Population, s.Tick)
 	s.ExecuteActions(c, actionLevels)
 }
 
 func (s *Simulation) Print() {
 	s.Grid.Print()
-	fmt.Printf("Population Size: %d", len(s.Population.Creatures))
+	fmt.Printf("Population Size: %d", len(s.Creatures()))
 }
 
-// ExecuteActions delegates all action-related logic to dedicated handlers for clarity and maintainability.
+// ExecuteActions runs each enabled action from the registry, then combines the movement actions into a single move.
 func (s *Simulation) ExecuteActions(c *Creature, actionLevels []float32) {
-	s.handleResponsiveness(c, actionLevels)
-	s.handleOscillatorPeriod(c, actionLevels)
+	move := Movement{}
+	for id, action := range actions {
+		if action.Enabled && id < len(actionLevels) {
+			action.Execute(s, c, actionLevels[id], &move)
+		}
+	}
 
 	responseAdjust := responseCurve(c.Responsiveness)
-	moveX, moveY := s.handleMovement(c, actionLevels, responseAdjust)
+	moveX := float32(math.Tanh(float64(move.X))) * responseAdjust
+	moveY := float32(math.Tanh(float64(move.Y))) * responseAdjust
 
 	moveXSign, moveYSign := 1, 1
 	if moveX < 0 {
@@ -140,7 +254,7 @@ func (s *Simulation) ExecuteActions(c *Creature, actionLevels []float32) {
 	movementOffset := grid.Dir{X: moveXBool * moveXSign, Y: moveYBool * moveYSign}
 	newCoord := c.GetNextLoc(movementOffset)
 	if s.Grid.IsInBounds(newCoord) && s.Grid.IsEmptyAt(newCoord) {
-		s.Population.QueueForMove(c, newCoord)
+		c.Population.QueueForMove(c, newCoord)
 	}
 }
 
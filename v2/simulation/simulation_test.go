@@ -1,6 +1,7 @@
 package simulation
 
 import (
+	"biogo/v2/grid"
 	"testing"
 )
 
@@ -15,16 +16,47 @@ func TestSimulation_InitializeGrid(t *testing.T) {
 
 func TestSimulation_InitializeFirstGeneration(t *testing.T) {
 	sim := New()
-	sim.Population = nil
+	sim.Populations = nil
 	sim.InitializeFirstGeneration()
-	if sim.Population == nil {
-		t.Fatal("InitializeFirstGeneration should set Population")
+	if len(sim.Populations) == 0 {
+		t.Fatal("InitializeFirstGeneration should set Populations")
 	}
-	if len(sim.Population.Creatures) == 0 {
+	if len(sim.Populations[0].Creatures) == 0 {
 		t.Fatal("Population should have creatures after InitializeFirstGeneration")
 	}
 }
 
+func TestSimulation_InitializeNewGeneration_PlacesEveryCreature(t *testing.T) {
+	defer smallWorld()()
+	sim := New()
+	sim.RunGeneration()
+	if sim.Generation != 1 {
+		t.Fatalf("Generation = %d after one generation, want 1", sim.Generation)
+	}
+
+	// Generation 2: each creature is on the grid under its own id, and nothing else is
+	occupied := 0
+	for x := 0; x < sim.Grid.SizeX(); x++ {
+		for y := 0; y < sim.Grid.SizeY(); y++ {
+			if sim.Grid.IsOccupiedAt(grid.Coord{X: x, Y: y}) {
+				occupied++
+			}
+		}
+	}
+	creatures := sim.Creatures()
+	if occupied != len(creatures) {
+		t.Errorf("%d cells are occupied by %d creatures", occupied, len(creatures))
+	}
+	for _, c := range creatures {
+		if got := sim.Grid.At(c.Loc); got != c.Id {
+			t.Errorf("Creature %d's cell %v holds %d", c.Id, c.Loc, got)
+		}
+		if got := sim.CreatureAt(c.Loc); got != c {
+			t.Errorf("CreatureAt(%v) doesn't find creature %d", c.Loc, c.Id)
+		}
+	}
+}
+
 func TestSimulation_Update_PanicsOnMaxGenerations(t *testing.T) {
 	sim := New()
 	sim.Generation = Params.MaxGenerations
@@ -44,7 +76,7 @@ func TestSimulation_Print(t *testing.T) {
 
 func TestSimulation_StepCreature(t *testing.T) {
 	sim := New()
-	c := sim.Population.Creatures[0]
+	c := sim.Populations[0].Creatures[0]
 	ageBefore := c.Age
 	sim.StepCreature(c)
 	if c.Age != ageBefore+1 {
@@ -54,7 +86,7 @@ func TestSimulation_StepCreature(t *testing.T) {
 
 func TestSimulation_ExecuteActions_Movement(t *testing.T) {
 	sim := New()
-	c := sim.Population.Creatures[0]
+	c := sim.Populations[0].Creatures[0]
 	c.Alive = true
 
 	// Place creature away from the east edge
@@ -73,7 +105,7 @@ func TestSimulation_ExecuteActions_Movement(t *testing.T) {
 	moved := false
 	for i := 0; i < 100; i++ { // Try up to 10 times to account for probabilistic movement
 		sim.ExecuteActions(c, actionLevels)
-		sim.Population.ProcessMoveQueue(sim.Grid)
+		sim.Populations[0].ProcessMoveQueue(sim.Grid)
 		if c.Loc != oldLoc {
 			moved = true
 			break
@@ -86,7 +118,7 @@ func TestSimulation_ExecuteActions_Movement(t *testing.T) {
 
 func TestSimulation_ExecuteActions_Responsiveness(t *testing.T) {
 	sim := New()
-	c := sim.Population.Creatures[0]
+	c := sim.Populations[0].Creatures[0]
 	c.Alive = true
 	oldResp := c.Responsiveness
 	actionLevels := make([]float32, 16)
@@ -101,7 +133,7 @@ func TestSimulation_ExecuteActions_Responsiveness(t *testing.T) {
 
 func TestSimulation_ExecuteActions_OscillatorPeriod(t *testing.T) {
 	sim := New()
-	c := sim.Population.Creatures[0]
+	c := sim.Populations[0].Creatures[0]
 	c.Alive = true
 	oldClock := c.Clock
 	actionLevels := make([]float32, 16)
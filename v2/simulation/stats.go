@@ -0,0 +1,163 @@
+// stats.go: Per generation statistics, kept in memory and optionally written out as CSV.
+
+package simulation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// GenerationStats summarises one population's generation once it's over.
+type GenerationStats struct {
+	Generation   int
+	Population   string
+	Size         int // Creatures the generation started with
+	Survivors    int // Creatures that were alive and passed the challenge
+	SurvivalRate float32
+	Kills        int     // Creatures this population killed
+	Killed       int     // Creatures of this population killed by others
+	Immigrants   int     // Genomes that arrived from other islands at the start of the generation
+	Diversity    float32 // Mean genome dissimilarity between random pairs of creatures, if measured
+	Species      int     // Species among a sample of the creatures, see Population.Species, if measured
+	BrainSize    float32 // Mean connections in a creature's pruned brain
+}
+
+var statsHeader = []string{"generation", "population", "size", "survivors", "survival_rate", "kills", "killed", "immigrants", "diversity", "species", "brain_size"}
+
+func (st GenerationStats) record() []string {
+	return []string{
+		strconv.Itoa(st.Generation),
+		st.Population,
+		strconv.Itoa(st.Size),
+		strconv.Itoa(st.Survivors),
+		strconv.FormatFloat(float64(st.SurvivalRate), 'f', 4, 32),
+		strconv.Itoa(st.Kills),
+		strconv.Itoa(st.Killed),
+		strconv.Itoa(st.Immigrants),
+		strconv.FormatFloat(float64(st.Diversity), 'f', 4, 32),
+		strconv.Itoa(st.Species),
+		strconv.FormatFloat(float64(st.BrainSize), 'f', 2, 32),
+	}
+}
+
+// StatsRecorder keeps the stats of every finished generation, a row per population. The zero value records in memory only.
+type StatsRecorder struct {
+	History []GenerationStats
+	// Diversity turns on measuring Diversity and Species, which compare genomes and are slow. They're
+	// always measured when writing CSV, and 0 otherwise.
+	Diversity bool
+	csv       *csv.Writer
+}
+
+// WriteCSV writes the header to w, then a row for every generation recorded from now on.
+func (r *StatsRecorder) WriteCSV(w io.Writer) error {
+	r.csv = csv.NewWriter(w)
+	return r.write(statsHeader)
+}
+
+// measuresDiversity reports whether the generations' Diversity and Species are wanted.
+func (r *StatsRecorder) measuresDiversity() bool {
+	return r.Diversity || r.csv != nil
+}
+
+func (r *StatsRecorder) Record(st GenerationStats) error {
+	r.History = append(r.History, st)
+	if r.csv == nil {
+		return nil
+	}
+	return r.write(st.record())
+}
+
+func (r *StatsRecorder) write(row []string) error {
+	if err := r.csv.Write(row); err != nil {
+		return err
+	}
+	r.csv.Flush()
+	return r.csv.Error()
+}
+
+// ReadStatsCSV reads back stats written by WriteCSV.
+func ReadStatsCSV(r io.Reader) ([]GenerationStats, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("stats file is empty")
+	}
+	history := []GenerationStats{}
+	for _, row := range rows[1:] {
+		if len(row) != len(statsHeader) {
+			return nil, fmt.Errorf("stats row has %d columns, want %d", len(row), len(statsHeader))
+		}
+		ints := make([]int, len(row))
+		floats := make([]float64, len(row))
+		for i := range row {
+			switch i {
+			case 1:
+			case 4, 8, 10:
+				floats[i], err = strconv.ParseFloat(row[i], 32)
+			default:
+				ints[i], err = strconv.Atoi(row[i])
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		history = append(history, GenerationStats{
+			Generation:   ints[0],
+			Population:   row[1],
+			Size:         ints[2],
+			Survivors:    ints[3],
+			SurvivalRate: float32(floats[4]),
+			Kills:        ints[5],
+			Killed:       ints[6],
+			Immigrants:   ints[7],
+			Diversity:    float32(floats[8]),
+			Species:      ints[9],
+			BrainSize:    float32(floats[10]),
+		})
+	}
+	return history, nil
+}
+
+// CombinePopulations sums each generation's rows into one, with the survival rate over all populations.
+// Species are summed too, and diversity and brain size averaged weighted by size.
+func CombinePopulations(history []GenerationStats) []GenerationStats {
+	combined := []GenerationStats{}
+	for _, st := range history {
+		n := len(combined)
+		if n == 0 || combined[n-1].Generation != st.Generation {
+			combined = append(combined, GenerationStats{Generation: st.Generation, Population: "all"})
+			n++
+		}
+		c := &combined[n-1]
+		if size := c.Size + st.Size; size > 0 {
+			c.Diversity = (c.Diversity*float32(c.Size) + st.Diversity*float32(st.Size)) / float32(size)
+			c.BrainSize = (c.BrainSize*float32(c.Size) + st.BrainSize*float32(st.Size)) / float32(size)
+		}
+		c.Species += st.Species
+		c.Size += st.Size
+		c.Survivors += st.Survivors
+		c.Kills += st.Kills
+		c.Killed += st.Killed
+		c.Immigrants += st.Immigrants
+		if c.Size > 0 {
+			c.SurvivalRate = float32(c.Survivors) / float32(c.Size)
+		}
+	}
+	return combined
+}
+
+// GenerationsToSurvival returns the first generation in which the survival rate over all
+// populations reached target, or 0 if none did.
+func GenerationsToSurvival(history []GenerationStats, target float32) int {
+	for _, st := range CombinePopulations(history) {
+		if st.SurvivalRate >= target {
+			return st.Generation
+		}
+	}
+	return 0
+}
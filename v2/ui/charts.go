@@ -0,0 +1,96 @@
+// charts.go: Rolling line charts of the per generation stats, drawn over the bottom left of the grid.
+
+package ui
+
+import (
+	"biogo/v2/simulation"
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/colornames"
+)
+
+const (
+	chartWidth       = 220
+	chartHeight      = 60
+	chartTitle       = 16 // Height of the title above each chart
+	chartGap         = 10
+	chartGenerations = 100 // Generations each chart shows
+)
+
+type chart struct {
+	title  string
+	format string
+	max    float32 // Top of the chart, or 0 to fit the data
+	value  func(st simulation.GenerationStats) float32
+}
+
+var charts = []chart{
+	{"Survival", "%.1f%%", 100, func(st simulation.GenerationStats) float32 { return st.SurvivalRate * 100 }},
+	{"Diversity", "%.3f", 1, func(st simulation.GenerationStats) float32 { return st.Diversity }},
+	{"Species", "%.0f", 0, func(st simulation.GenerationStats) float32 { return float32(st.Species) }},
+	{"Brain size", "%.1f", 0, func(st simulation.GenerationStats) float32 { return st.BrainSize }},
+}
+
+// recentStats returns the last chartGenerations generations from the stats recorder, with the
+// populations combined.
+func (g *Game) recentStats() []simulation.GenerationStats {
+	history := g.Simulation.Stats.History
+	if rows := chartGenerations * len(g.Simulation.Populations); len(history) > rows {
+		history = history[len(history)-rows:]
+	}
+	combined := simulation.CombinePopulations(history)
+	if len(combined) > chartGenerations {
+		combined = combined[len(combined)-chartGenerations:]
+	}
+	return combined
+}
+
+// drawCharts draws the charts two to a row, above the controls help.
+func (g *Game) drawCharts(screen *ebiten.Image) {
+	history := g.recentStats()
+	bottom := g.Viewport.Height - 30
+	rows := (len(charts) + 1) / 2
+	for i, c := range charts {
+		x := chartGap + (i%2)*(chartWidth+chartGap)
+		y := bottom - (rows-i/2)*(chartHeight+chartTitle+chartGap)
+		c.draw(screen, history, float64(x), float64(y))
+	}
+}
+
+func (c chart) draw(screen *ebiten.Image, history []simulation.GenerationStats, x, y float64) {
+	ebitenutil.DrawRect(screen, x, y, chartWidth, chartHeight+chartTitle, color.RGBA{30, 30, 40, 200})
+	title := c.title
+	if len(history) > 0 {
+		title += ": " + fmt.Sprintf(c.format, c.value(history[len(history)-1]))
+	}
+	text.Draw(screen, title, panelFont, int(x)+4, int(y)+12, color.White)
+	if len(history) < 2 {
+		return
+	}
+
+	top := c.max
+	if top == 0 {
+		for _, st := range history {
+			if v := c.value(st); v > top {
+				top = v
+			}
+		}
+		if top == 0 {
+			top = 1
+		}
+	}
+	plotY := y + chartTitle
+	point := func(i int) (float64, float64) {
+		v := c.value(history[i]) / top
+		return x + float64(i)*chartWidth/float64(chartGenerations-1), plotY + chartHeight*(1-float64(v))
+	}
+	for i := 1; i < len(history); i++ {
+		x1, y1 := point(i - 1)
+		x2, y2 := point(i)
+		ebitenutil.DrawLine(screen, x1, y1, x2, y2, colornames.Lightgreen)
+	}
+}
@@ -0,0 +1,104 @@
+// controls.go: Keyboard controls for pausing, stepping, skipping and speeding up the simulation.
+
+package ui
+
+import (
+	"biogo/v2/render"
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const maxSpeed = 64
+
+// fastForwardBudget is how long fast forward runs the simulation for each frame.
+var fastForwardBudget = 15 * time.Millisecond
+
+const controlsHelp = "Space: pause  Right: step  N: next generation  +/-: speed  F: fast forward  C: charts  M: colours  Wheel/drag/0: zoom/pan/fit  Click: inspect"
+
+// Controls is the playback state the keyboard changes.
+type Controls struct {
+	Paused      bool
+	Speed       int  // Simulation updates per frame
+	FastForward bool // Run as many updates as fit in a frame, without drawing the grid
+	Charts      bool // Show the stats charts
+	ColorMode   render.ColorMode
+
+	step bool // Run a single update while paused
+	skip bool // Run to the start of the next generation
+}
+
+func NewControls() Controls {
+	return Controls{Speed: 1, Charts: true}
+}
+
+// readKeys updates the controls with the keys pressed since the last frame.
+func (c *Controls) readKeys() {
+	pressed := inpututil.IsKeyJustPressed
+	if pressed(ebiten.KeySpace) {
+		c.Paused = !c.Paused
+	}
+	if pressed(ebiten.KeyArrowRight) || pressed(ebiten.KeyPeriod) {
+		c.step = true
+	}
+	if pressed(ebiten.KeyN) {
+		c.skip = true
+	}
+	if pressed(ebiten.KeyEqual) || pressed(ebiten.KeyNumpadAdd) {
+		c.Speed = min(c.Speed*2, maxSpeed)
+	}
+	if pressed(ebiten.KeyMinus) || pressed(ebiten.KeyNumpadSubtract) {
+		c.Speed = max(c.Speed/2, 1)
+	}
+	if pressed(ebiten.KeyF) {
+		c.FastForward = !c.FastForward
+	}
+	if pressed(ebiten.KeyC) {
+		c.Charts = !c.Charts
+	}
+	if pressed(ebiten.KeyM) {
+		c.ColorMode = c.ColorMode.Next()
+	}
+}
+
+// Status describes the playback state for the overlay.
+func (c *Controls) Status() string {
+	switch {
+	case c.Paused:
+		return "Paused"
+	case c.FastForward:
+		return "Fast forward"
+	}
+	return fmt.Sprintf("Speed: x%d", c.Speed)
+}
+
+// advance runs the simulation as far as the controls say for this frame.
+func (g *Game) advance() {
+	sim := g.Simulation
+	c := &g.Controls
+	switch {
+	case c.skip:
+		c.skip, c.step = false, false
+		generation := sim.Generation
+		for sim.Generation == generation {
+			sim.Update()
+		}
+	case c.Paused:
+		if c.step {
+			c.step = false
+			sim.Update()
+		}
+	case c.FastForward:
+		start := time.Now()
+		for time.Since(start) < fastForwardBudget {
+			sim.Update()
+		}
+	default:
+		c.step = false
+		for i := 0; i < c.Speed; i++ {
+			sim.Update()
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package ui
 
 import (
+	"biogo/v2/render"
 	"biogo/v2/simulation"
 	"fmt"
 	"image/color"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
@@ -15,13 +17,26 @@ import (
 type Game struct {
 	Simulation *simulation.Simulation
 	Grid       *Grid
+	Controls   Controls
+	Selected   *simulation.Creature // Shown in the inspector panel
+	Viewport   Viewport
 	statLine   *StatLine
+
+	world *ebiten.Image // The grid at BlockSize pixels a cell, which the viewport shows part of
+	drawn cellsState    // What the grid's cells layer last showed
+}
+
+// cellsState is the simulation step and colour mode the cells layer was drawn for.
+type cellsState struct {
+	generation, tick int
+	mode             render.ColorMode
 }
 
 var (
 	BlockSize int = 2
 
-	statFont font.Face
+	statFont  font.Face
+	panelFont font.Face
 )
 
 func init() {
@@ -37,70 +52,117 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+	panelFont, err = opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    12,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		panic(err)
+	}
 }
 
 func NewGame(sim *simulation.Simulation) *Game {
+	width, height := sim.Grid.SizeX()*BlockSize, sim.Grid.SizeY()*BlockSize
 	g := Game{
 		Simulation: sim,
-		Grid:       NewGrid(0, 0, BlockSize),
-	}
-	for _, creature := range g.Simulation.Population.Creatures {
-		red, green, blue, alpha := creature.Genome.ToColor()
-		c := color.RGBA{
-			R: red,
-			G: green,
-			B: blue,
-			A: alpha,
-		}
-		img := g.Grid.AddBlob(BlockSize, c)
-		img.Translate(float64(creature.Loc.X*int(BlockSize)), float64(creature.Loc.Y*int(BlockSize)))
+		Grid:       NewGrid(0, 0, BlockSize, sim.Grid.SizeX(), sim.Grid.SizeY()),
+		Controls:   NewControls(),
+		Viewport:   NewViewport(width, height),
+		world:      ebiten.NewImage(width, height),
 	}
-
-	width := 5
-	center := g.Simulation.Grid.SizeX() / 2
-	minX := center - width/2
-	maxX := center + width/2
-	minY := g.Simulation.Grid.SizeY() / 4
-	maxY := minY + g.Simulation.Grid.SizeY()/2
-	g.Grid.AddLine(float64(minX*BlockSize), float64(minY*BlockSize), float64(maxX*BlockSize), float64(maxY*BlockSize))
+	g.Grid.SetTerrain(render.Terrain(sim, BlockSize))
+	g.updateCells()
 	return &g
 }
 
+// updateCells redraws the barriers and creatures, if the simulation has stepped or the colour mode
+// has changed since they were last drawn.
+func (g *Game) updateCells() {
+	state := cellsState{g.Simulation.Generation, g.Simulation.Tick, g.Controls.ColorMode}
+	if g.Grid.cellsDrawn && state == g.drawn {
+		return
+	}
+	g.Grid.SetBarriers(g.Simulation.Grid.BarrierCells())
+	g.Grid.SetCreatures(g.Simulation.Creatures(), g.Controls.ColorMode)
+	g.drawn = state
+}
+
 func (g *Game) Update() error {
+	g.Controls.readKeys()
+	if x, y, ok := g.Viewport.readMouse(); ok {
+		g.selectAt(x, y)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.Selected = nil
+	}
+	// Diversity and species are slow to measure, so only while they're charted
+	g.Simulation.Stats.Diversity = g.Controls.Charts
 	lastGeneration := g.Simulation.Generation
-	g.Simulation.Update()
+	g.advance()
 	if g.Simulation.Generation != lastGeneration {
-		g.Grid.blobs = []*Blob{}
-		for _, creature := range g.Simulation.Population.Creatures {
-			red, green, blue, alpha := creature.Genome.ToColor()
-			c := color.RGBA{
-				R: red,
-				G: green,
-				B: blue,
-				A: alpha,
-			}
-			img := g.Grid.AddBlob(BlockSize, c)
-			img.Translate(float64(creature.Loc.X*int(BlockSize)), float64(creature.Loc.Y*int(BlockSize)))
-		}
+		g.Selected = nil
+		// The schedule may have changed the map or the challenges
+		g.Grid.SetTerrain(render.Terrain(g.Simulation, BlockSize))
 	}
-	for i, creature := range g.Simulation.Population.Creatures {
-		img := g.Grid.blobs[i]
-		img.Move(float64(creature.Loc.X*int(BlockSize)), float64(creature.Loc.Y*int(BlockSize)))
+	if !g.Controls.FastForward {
+		g.updateCells()
 	}
 	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{15, 15, 15, 255})
-	g.Grid.DrawGrid(screen)
-	g.AddStatLine(screen, "Population", len(g.Simulation.Population.Creatures), 1)
-	g.AddStatLine(screen, "Generation", g.Simulation.Generation, 2)
+	if !g.Controls.FastForward {
+		g.world.Fill(render.Background)
+		g.Grid.DrawGrid(g.world)
+		g.drawSelection(g.world)
+		screen.DrawImage(g.world, &ebiten.DrawImageOptions{GeoM: g.Viewport.GeoM()})
+		g.Viewport.drawMinimap(screen, g.world)
+		g.drawInspector(screen)
+	}
+	if g.Controls.Charts {
+		g.drawCharts(screen)
+	}
+	line := 1
+	kills := 0
+	for _, pop := range g.Simulation.Populations {
+		name := "Population"
+		if len(g.Simulation.Populations) > 1 {
+			name = pop.Name
+		}
+		g.AddStatLine(screen, name, len(pop.Creatures), line)
+		kills += pop.Kills
+		line++
+	}
+	g.AddStatLine(screen, "Generation", g.Simulation.Generation, line)
+	line++
+	if simulation.IsActionEnabled(simulation.KILL_FORWARD) {
+		g.AddStatLine(screen, "Kills", kills, line)
+		line++
+	}
+	g.AddStatText(screen, g.Controls.Status(), line)
+	line++
+	g.AddStatText(screen, "Colour: "+g.Controls.ColorMode.String(), line)
+	text.Draw(screen, controlsHelp, statFont, 10, g.Viewport.Height-10, color.White)
 }
 
+// Layout fits the grid to the window whenever it's resized.
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	if outsideWidth != g.Viewport.Width || outsideHeight != g.Viewport.Height {
+		g.Viewport.Resize(outsideWidth, outsideHeight)
+	}
 	return outsideWidth, outsideHeight
 }
 
 func (g *Game) AddStatLine(img *ebiten.Image, description string, statLine int, count int) {
-	text.Draw(img, fmt.Sprintf("%s: %d", description, statLine), statFont, g.Simulation.Grid.SizeX()*BlockSize-200, 20*count+3, color.White)
+	g.AddStatText(img, fmt.Sprintf("%s: %d", description, statLine), count)
+}
+
+func (g *Game) AddStatText(img *ebiten.Image, str string, count int) {
+	x := g.Viewport.Width - 200
+	if g.Selected != nil {
+		x -= PanelWidth
+	}
+	text.Draw(img, str, statFont, x, 20*count+3, color.White)
 }
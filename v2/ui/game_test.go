@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"biogo/v2/render"
 	"biogo/v2/simulation"
+	"image/color"
 	"testing"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -40,3 +42,166 @@ func TestAddStatLineDoesNotPanic(t *testing.T) {
 	// Should not panic
 	game.AddStatLine(img, "TestStat", 42, 1)
 }
+
+func TestGameControlsAdvanceTheSimulation(t *testing.T) {
+	saved := *simulation.Params
+	defer func() { *simulation.Params = saved }()
+	simulation.Params.MaxAge = 20
+	simulation.Params.Challenge = simulation.AllSurvive
+	game := NewGame(simulation.New())
+	sim := game.Simulation
+
+	game.Controls.Paused = true
+	game.advance()
+	if sim.Tick != 0 {
+		t.Errorf("Paused game ran %d ticks, want 0", sim.Tick)
+	}
+	game.Controls.step = true
+	game.advance()
+	game.advance()
+	if sim.Tick != 1 {
+		t.Errorf("Single step ran %d ticks, want 1", sim.Tick)
+	}
+
+	game.Controls.Paused = false
+	game.Controls.Speed = 4
+	game.advance()
+	if sim.Tick != 5 {
+		t.Errorf("Speed x4 should run 4 ticks a frame, at tick %d, want 5", sim.Tick)
+	}
+
+	game.Controls.skip = true
+	game.advance()
+	if sim.Generation != 1 || sim.Tick != 0 {
+		t.Errorf("Skip should stop at the start of the next generation, at generation %d tick %d", sim.Generation, sim.Tick)
+	}
+	if game.Controls.Status() != "Speed: x4" {
+		t.Errorf("Status = %q, want the speed", game.Controls.Status())
+	}
+}
+
+func TestClickSelectsCreature(t *testing.T) {
+	game := NewGame(simulation.New())
+	c := game.Simulation.Creatures()[0]
+	game.selectAt(c.Loc.X*BlockSize, c.Loc.Y*BlockSize)
+	if game.Selected != c {
+		t.Fatalf("Clicking creature %d selected %v", c.Id, game.Selected)
+	}
+	// Should not panic
+	game.Draw(ebiten.NewImage(game.Simulation.Grid.SizeX()*BlockSize, game.Simulation.Grid.SizeY()*BlockSize))
+}
+
+func TestChartsShowRecentGenerations(t *testing.T) {
+	game := NewGame(simulation.New())
+	for gen := 1; gen <= 150; gen++ {
+		game.Simulation.Stats.Record(simulation.GenerationStats{Generation: gen, Size: 10, Survivors: 5, Species: 2})
+	}
+	history := game.recentStats()
+	if len(history) != chartGenerations || history[len(history)-1].Generation != 150 {
+		t.Fatalf("Charts should show the last %d generations, got %d ending at %d", chartGenerations, len(history), history[len(history)-1].Generation)
+	}
+	// Should not panic
+	game.drawCharts(ebiten.NewImage(800, 600))
+}
+
+func TestCreaturesDrawnFromOneBuffer(t *testing.T) {
+	saved := *simulation.Params
+	defer func() { *simulation.Params = saved }()
+	simulation.Params.MaxAge = 20
+	simulation.Params.Challenge = simulation.AllSurvive
+	game := NewGame(simulation.New())
+	cells, pixels := game.Grid.cells, &game.Grid.pixels[0]
+
+	pixel := func(c *simulation.Creature) color.RGBA {
+		i := (c.Loc.Y*game.Grid.sizeX + c.Loc.X) * 4
+		p := game.Grid.pixels[i : i+4]
+		return color.RGBA{p[0], p[1], p[2], p[3]}
+	}
+	game.Controls.Paused = true
+	game.Controls.ColorMode = render.ColorSightDistance
+	game.Update()
+	c := game.Simulation.Creatures()[0]
+	if want := render.ColorSightDistance.Color(c); pixel(c) != want {
+		t.Errorf("Creature drawn as %v, want %v", pixel(c), want)
+	}
+
+	// Paused, nothing changes, so the layer isn't redrawn
+	clear(game.Grid.pixels)
+	game.Update()
+	if pixel(c) != (color.RGBA{}) {
+		t.Error("The creature layer was redrawn while paused")
+	}
+
+	game.Controls.skip = true
+	game.Update()
+	if game.Simulation.Generation != 1 {
+		t.Fatalf("Skip should reach generation 1, at %d", game.Simulation.Generation)
+	}
+	if game.Grid.cells != cells || &game.Grid.pixels[0] != pixels {
+		t.Error("A new generation shouldn't allocate a new creature layer")
+	}
+	c = game.Simulation.Creatures()[0]
+	if want := render.ColorSightDistance.Color(c); pixel(c) != want {
+		t.Errorf("New generation's creature drawn as %v, want %v", pixel(c), want)
+	}
+}
+
+func TestTerrainShowsWallsAndZone(t *testing.T) {
+	saved := *simulation.Params
+	defer func() { *simulation.Params = saved }()
+	simulation.Params.Challenge = simulation.Center
+	game := NewGame(simulation.New())
+	if game.Grid.terrain == nil {
+		t.Fatal("NewGame should draw the terrain")
+	}
+	w, h := game.Grid.terrain.Size()
+	if w != game.Simulation.Grid.SizeX()*BlockSize || h != game.Simulation.Grid.SizeY()*BlockSize {
+		t.Errorf("Terrain is %dx%d, want the grid's size", w, h)
+	}
+	center := game.Simulation.Grid.SizeX() / 2 * BlockSize
+	if got := game.Grid.terrain.At(center, 1); got != render.Background {
+		t.Errorf("Top middle drawn as %v, want the background outside the Center zone", got)
+	}
+}
+
+func TestViewportZoomAndPan(t *testing.T) {
+	v := NewViewport(1200, 800)
+	v.Resize(600, 400)
+	if v.Zoom != 0.5 || v.X != 0 || v.Y != 0 || v.ZoomedIn() {
+		t.Fatalf("Resize should fit the world, got zoom %f at %f,%f", v.Zoom, v.X, v.Y)
+	}
+
+	v.ZoomAt(300, 200, 4)
+	if wx, wy := v.ToWorld(300, 200); v.Zoom != 2 || wx != 600 || wy != 400 {
+		t.Errorf("Zooming about the centre gave zoom %f with %f,%f under it, want 2 and 600,400", v.Zoom, wx, wy)
+	}
+	if !v.ZoomedIn() {
+		t.Error("At zoom 2 part of the world should be off screen")
+	}
+	x := v.X
+	v.Pan(100, 0)
+	if v.X != x-50 {
+		t.Errorf("Panning 100 pixels at zoom 2 moved the view to %f, want %f", v.X, x-50)
+	}
+	v.Pan(100000, 100000)
+	if wx, wy := v.ToWorld(300, 200); wx < 0 || wy < 0 {
+		t.Errorf("Panning should keep the world under the centre of the screen, got %f,%f", wx, wy)
+	}
+
+	v.ZoomAt(0, 0, 1000)
+	if v.Zoom != maxZoom {
+		t.Errorf("Zoom = %f, want it capped at %d", v.Zoom, maxZoom)
+	}
+}
+
+func TestClickSelectsThroughTheViewport(t *testing.T) {
+	game := NewGame(simulation.New())
+	game.Layout(400, 300)
+	c := game.Simulation.Creatures()[0]
+	game.Viewport.Zoom = 4
+	game.Viewport.CenterOn(float64(c.Loc.X*BlockSize), float64(c.Loc.Y*BlockSize))
+	game.selectAt(200, 150)
+	if game.Selected != c {
+		t.Fatalf("Clicking the centre of a view centred on creature %d selected %v", c.Id, game.Selected)
+	}
+}
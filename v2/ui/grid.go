@@ -1,6 +1,10 @@
 package ui
 
 import (
+	"biogo/v2/grid"
+	"biogo/v2/render"
+	"biogo/v2/simulation"
+	"image"
 	"image/color"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -15,36 +19,66 @@ type Grid struct {
 	position point
 	blobSize int
 
-	blobs []*Blob
+	terrain *ebiten.Image // Zones and static walls, drawn under everything else
 
-	walls []*Line
+	barrierCells []grid.Coord
+
+	// cells has a pixel per grid cell for the barriers and creatures, drawn scaled up by blobSize.
+	// Its pixels are rewritten in one go when the simulation steps, so nothing is allocated per creature.
+	cells      *ebiten.Image
+	pixels     []byte
+	sizeX      int
+	cellsDrawn bool // SetCreatures has filled cells
 }
 
-func NewGrid(xPos, yPos float64, blobSize int) *Grid {
-	grid := &Grid{position: point{X: xPos, Y: yPos}, blobSize: blobSize}
-	return grid
+func NewGrid(xPos, yPos float64, blobSize, sizeX, sizeY int) *Grid {
+	return &Grid{
+		position: point{X: xPos, Y: yPos},
+		blobSize: blobSize,
+		cells:    ebiten.NewImage(sizeX, sizeY),
+		pixels:   make([]byte, sizeX*sizeY*4),
+		sizeX:    sizeX,
+	}
 }
 
 func (g *Grid) DrawGrid(image *ebiten.Image) {
-	for _, wall := range g.walls {
-		wall.Draw(image)
+	if g.terrain != nil {
+		image.DrawImage(g.terrain, &ebiten.DrawImageOptions{})
 	}
-	for _, blob := range g.blobs {
-		blob.Draw(image)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(g.blobSize), float64(g.blobSize))
+	image.DrawImage(g.cells, op)
+}
+
+// SetTerrain replaces the terrain drawn under the creatures.
+func (g *Grid) SetTerrain(img image.Image) {
+	if g.terrain != nil {
+		g.terrain.Dispose()
 	}
+	g.terrain = ebiten.NewImageFromImage(img)
 }
 
-func (g *Grid) AddLine(minX, minY, maxX, maxY float64) *Line {
-	wall := NewLine(minX, minY, maxX, maxY)
-	g.walls = append(g.walls, wall)
-	return wall
+// SetBarriers sets the cells of the moving barriers to draw.
+func (g *Grid) SetBarriers(cells []grid.Coord) {
+	g.barrierCells = cells
+}
+
+// SetCreatures redraws the cells layer with the barriers and the living creatures, coloured by mode.
+func (g *Grid) SetCreatures(creatures []*simulation.Creature, mode render.ColorMode) {
+	clear(g.pixels)
+	for _, cell := range g.barrierCells {
+		g.setPixel(cell, render.BarrierColor)
+	}
+	for _, c := range creatures {
+		if c.Alive {
+			g.setPixel(c.Loc, mode.Color(c))
+		}
+	}
+	g.cells.ReplacePixels(g.pixels)
+	g.cellsDrawn = true
 }
 
-func (g *Grid) AddBlob(blobWidth int, c color.Color) *Blob {
-	var newImage *ebiten.Image
-	newImage = ebiten.NewImage(blobWidth, blobWidth)
-	newImage.Fill(c)
-	blob := NewBlob(newImage, &ebiten.GeoM{})
-	g.blobs = append(g.blobs, blob)
-	return blob
+func (g *Grid) setPixel(loc grid.Coord, c color.RGBA) {
+	i := (loc.Y*g.sizeX + loc.X) * 4
+	g.pixels[i], g.pixels[i+1], g.pixels[i+2], g.pixels[i+3] = c.R, c.G, c.B, c.A
 }
@@ -0,0 +1,175 @@
+// inspector.go: The side panel showing the creature last clicked on: its fields, genome traits and decoded genes, live sensor and action values, and a diagram of its brain.
+
+package ui
+
+import (
+	"biogo/v2/grid"
+	"biogo/v2/simulation"
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/colornames"
+)
+
+const (
+	PanelWidth     = 340
+	panelLine      = 14
+	panelMargin    = 10
+	diagramHeight  = 240
+	nodeSize       = 8
+	selectionReach = 2 // Cells from the click a creature can be and still be picked
+)
+
+// selectAt picks the creature nearest the clicked pixel, or closes the panel if there's none nearby.
+func (g *Game) selectAt(x, y int) {
+	if g.Selected != nil && x >= g.panelX() {
+		return
+	}
+	wx, wy := g.Viewport.ToWorld(x, y)
+	click := grid.Coord{X: int(math.Floor(wx)) / BlockSize, Y: int(math.Floor(wy)) / BlockSize}
+	var nearest *simulation.Creature
+	best := math.MaxInt
+	for dx := -selectionReach; dx <= selectionReach; dx++ {
+		for dy := -selectionReach; dy <= selectionReach; dy++ {
+			loc := grid.Coord{X: click.X + dx, Y: click.Y + dy}
+			if !g.Simulation.Grid.IsInBounds(loc) {
+				continue
+			}
+			if c := g.Simulation.CreatureAt(loc); c != nil && dx*dx+dy*dy < best {
+				nearest, best = c, dx*dx+dy*dy
+			}
+		}
+	}
+	g.Selected = nearest
+}
+
+// panelX is where the panel starts, against the right edge of the window.
+func (g *Game) panelX() int {
+	return g.Viewport.Width - PanelWidth
+}
+
+// drawSelection marks the selected creature on the world image.
+func (g *Game) drawSelection(world *ebiten.Image) {
+	if c := g.Selected; c != nil {
+		ebitenutil.DrawRect(world, float64(c.Loc.X*BlockSize-2), float64(c.Loc.Y*BlockSize-2), float64(BlockSize+4), float64(BlockSize+4), colornames.Yellow)
+	}
+}
+
+func (g *Game) drawInspector(screen *ebiten.Image) {
+	c := g.Selected
+	if c == nil {
+		return
+	}
+	left := g.panelX()
+	height := g.Viewport.Height
+	ebitenutil.DrawRect(screen, float64(left), 0, PanelWidth, float64(height), color.RGBA{30, 30, 40, 230})
+
+	x, y := left+panelMargin, panelMargin+panelLine
+	line := func(str string, clr color.Color) {
+		text.Draw(screen, str, panelFont, x, y, clr)
+		y += panelLine
+	}
+	population := ""
+	if c.Population != nil {
+		population = c.Population.Name
+	}
+	status := "alive"
+	if !c.Alive {
+		status = "dead"
+	}
+	line(fmt.Sprintf("Creature %d (%s, %s)", c.Id, population, status), colornames.Yellow)
+	line(fmt.Sprintf("Loc: %d,%d  Born: %d,%d  Facing: %d,%d", c.Loc.X, c.Loc.Y, c.BirthLoc.X, c.BirthLoc.Y, c.LastMoveDir.X, c.LastMoveDir.Y), color.White)
+	line(fmt.Sprintf("Age: %d  Energy: %.2f  Responsiveness: %.2f  Clock: %d", c.Age, c.Energy, c.Responsiveness, c.Clock), color.White)
+	y += panelLine / 2
+
+	line("Genome", colornames.Yellow)
+	traits := c.Genome.Traits()
+	for i := 0; i < len(traits); i += 2 {
+		str := fmt.Sprintf("%s: %d", traits[i].Name, traits[i].Value)
+		if i+1 < len(traits) {
+			str = fmt.Sprintf("%-22s %s: %d", str, traits[i+1].Name, traits[i+1].Value)
+		}
+		line(str, color.White)
+	}
+	y += panelLine / 2
+
+	line("Genes", colornames.Yellow)
+	for _, gene := range simulation.DecodeGenes(c.Genome.Brain, c.Genome.NeuronCount) {
+		line(gene.String(), color.White)
+	}
+	y += panelLine / 2
+
+	brain := c.Brain()
+	for _, kind := range []string{simulation.NODE_SENSOR, simulation.NODE_ACTION} {
+		title := "Sensors"
+		if kind == simulation.NODE_ACTION {
+			title = "Action levels"
+		}
+		line(title, colornames.Yellow)
+		for _, node := range brain.Nodes {
+			if node.Kind == kind {
+				line(fmt.Sprintf("%-26s %+.3f", node.Name, node.Value), color.White)
+			}
+		}
+		y += panelLine / 2
+	}
+
+	top := max(y, height-diagramHeight-panelMargin)
+	drawBrain(screen, brain, float64(left+panelMargin), float64(top), float64(PanelWidth-2*panelMargin), float64(height-top-panelMargin))
+}
+
+// drawBrain draws the net in three columns, sensors, neurons and actions. Nodes are green for
+// positive values and red for negative, edges the same for the signal they carried.
+func drawBrain(screen *ebiten.Image, brain simulation.BrainState, left, top, width, height float64) {
+	columns := map[string]float64{
+		simulation.NODE_SENSOR: left + 30,
+		simulation.NODE_NEURON: left + width/2,
+		simulation.NODE_ACTION: left + width - 30,
+	}
+	counts := map[string]int{}
+	for _, node := range brain.Nodes {
+		counts[node.Kind]++
+	}
+	positions := make([][2]float64, len(brain.Nodes))
+	seen := map[string]int{}
+	for i, node := range brain.Nodes {
+		seen[node.Kind]++
+		positions[i] = [2]float64{columns[node.Kind], top + height*float64(seen[node.Kind])/float64(counts[node.Kind]+1)}
+	}
+
+	for _, e := range brain.Edges {
+		from, to := positions[e.Source], positions[e.Target]
+		if e.Source == e.Target {
+			ebitenutil.DrawRect(screen, from[0]-nodeSize, from[1]-nodeSize, nodeSize*2, nodeSize*2, valueColor(e.Signal))
+			continue
+		}
+		ebitenutil.DrawLine(screen, from[0], from[1], to[0], to[1], valueColor(e.Signal))
+	}
+	for i, node := range brain.Nodes {
+		p := positions[i]
+		ebitenutil.DrawRect(screen, p[0]-nodeSize/2, p[1]-nodeSize/2, nodeSize, nodeSize, colornames.White)
+		ebitenutil.DrawRect(screen, p[0]-nodeSize/2+1, p[1]-nodeSize/2+1, nodeSize-2, nodeSize-2, valueColor(node.Value))
+		label := node.Code
+		if label == "" {
+			label = node.Name
+		}
+		labelX := int(p[0]) + nodeSize
+		if node.Kind == simulation.NODE_SENSOR {
+			labelX = int(p[0]) - nodeSize - 26
+		}
+		text.Draw(screen, label, panelFont, labelX, int(p[1])+4, color.White)
+	}
+}
+
+// valueColor is green for positive values and red for negative, brighter the larger they are.
+func valueColor(v float32) color.Color {
+	strength := uint8(60 + 195*math.Min(1, math.Abs(float64(v))))
+	if v < 0 {
+		return color.RGBA{strength, 40, 40, 255}
+	}
+	return color.RGBA{40, strength, 40, 255}
+}
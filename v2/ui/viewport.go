@@ -0,0 +1,190 @@
+// viewport.go: The camera onto the grid: wheel zoom about the cursor, drag to pan, fit to the window on resize, and a minimap while zoomed in.
+
+package ui
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/image/colornames"
+)
+
+const (
+	minZoom       = 0.25
+	maxZoom       = 32
+	zoomStep      = 1.1 // Zoom change per wheel notch
+	dragThreshold = 4   // Pixels the cursor moves before a press is a drag rather than a click
+	minimapWidth  = 200
+	minimapMargin = 10
+)
+
+// Viewport maps the world image, the grid drawn BlockSize pixels to a cell, onto the screen.
+type Viewport struct {
+	Zoom          float64 // Screen pixels per world pixel
+	X, Y          float64 // World pixel at the screen's top left
+	Width, Height int     // Screen size
+
+	worldWidth, worldHeight int
+
+	pressX, pressY int // Where the left button went down
+	lastX, lastY   int
+	dragging       bool
+}
+
+func NewViewport(worldWidth, worldHeight int) Viewport {
+	v := Viewport{worldWidth: worldWidth, worldHeight: worldHeight}
+	v.Resize(worldWidth, worldHeight)
+	return v
+}
+
+// Resize fits the world to a new screen size.
+func (v *Viewport) Resize(width, height int) {
+	v.Width, v.Height = width, height
+	v.Fit()
+}
+
+// Fit zooms so the whole world fits the screen, centred.
+func (v *Viewport) Fit() {
+	v.Zoom = math.Min(float64(v.Width)/float64(v.worldWidth), float64(v.Height)/float64(v.worldHeight))
+	v.Zoom = math.Max(minZoom, math.Min(maxZoom, v.Zoom))
+	v.X = (float64(v.worldWidth) - float64(v.Width)/v.Zoom) / 2
+	v.Y = (float64(v.worldHeight) - float64(v.Height)/v.Zoom) / 2
+}
+
+// ToWorld converts a screen pixel to a world pixel.
+func (v *Viewport) ToWorld(x, y int) (float64, float64) {
+	return v.X + float64(x)/v.Zoom, v.Y + float64(y)/v.Zoom
+}
+
+// ZoomAt multiplies the zoom by factor, keeping the world pixel under the screen pixel x,y in place.
+func (v *Viewport) ZoomAt(x, y int, factor float64) {
+	wx, wy := v.ToWorld(x, y)
+	v.Zoom = math.Max(minZoom, math.Min(maxZoom, v.Zoom*factor))
+	v.X = wx - float64(x)/v.Zoom
+	v.Y = wy - float64(y)/v.Zoom
+	v.clamp()
+}
+
+// Pan moves the view by a distance in screen pixels.
+func (v *Viewport) Pan(dx, dy int) {
+	v.X -= float64(dx) / v.Zoom
+	v.Y -= float64(dy) / v.Zoom
+	v.clamp()
+}
+
+// CenterOn moves the view so the world pixel is at the centre of the screen.
+func (v *Viewport) CenterOn(wx, wy float64) {
+	v.X = wx - float64(v.Width)/v.Zoom/2
+	v.Y = wy - float64(v.Height)/v.Zoom/2
+	v.clamp()
+}
+
+// clamp keeps the world covering at least the centre of the screen.
+func (v *Viewport) clamp() {
+	viewWidth, viewHeight := float64(v.Width)/v.Zoom, float64(v.Height)/v.Zoom
+	v.X = math.Max(-viewWidth/2, math.Min(float64(v.worldWidth)-viewWidth/2, v.X))
+	v.Y = math.Max(-viewHeight/2, math.Min(float64(v.worldHeight)-viewHeight/2, v.Y))
+}
+
+// ZoomedIn reports whether part of the world is off screen.
+func (v *Viewport) ZoomedIn() bool {
+	return v.X > 0 || v.Y > 0 ||
+		v.X+float64(v.Width)/v.Zoom < float64(v.worldWidth) ||
+		v.Y+float64(v.Height)/v.Zoom < float64(v.worldHeight)
+}
+
+// GeoM places the world image on the screen.
+func (v *Viewport) GeoM() ebiten.GeoM {
+	geoM := ebiten.GeoM{}
+	geoM.Translate(-v.X, -v.Y)
+	geoM.Scale(v.Zoom, v.Zoom)
+	return geoM
+}
+
+// minimapRect is the minimap's position and size on the screen.
+func (v *Viewport) minimapRect() (x, y, width, height float64) {
+	width = minimapWidth
+	height = width * float64(v.worldHeight) / float64(v.worldWidth)
+	return minimapMargin, minimapMargin, width, height
+}
+
+func (v *Viewport) inMinimap(x, y int) bool {
+	if !v.ZoomedIn() {
+		return false
+	}
+	mx, my, mw, mh := v.minimapRect()
+	return float64(x) >= mx && float64(x) < mx+mw && float64(y) >= my && float64(y) < my+mh
+}
+
+// readMouse zooms and pans with the wheel and drags, and returns the screen pixel of a click that
+// wasn't a drag, or ok false. Clicking the minimap centres the view there instead.
+func (v *Viewport) readMouse() (x, y int, ok bool) {
+	cx, cy := ebiten.CursorPosition()
+	if _, wheel := ebiten.Wheel(); wheel != 0 {
+		v.ZoomAt(cx, cy, math.Pow(zoomStep, wheel))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.Key0) {
+		v.Fit()
+	}
+
+	switch {
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		v.pressX, v.pressY = cx, cy
+		v.lastX, v.lastY = cx, cy
+		v.dragging = false
+	case ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft):
+		if !v.dragging && (abs(cx-v.pressX) > dragThreshold || abs(cy-v.pressY) > dragThreshold) {
+			v.dragging = true
+		}
+		if v.dragging {
+			v.Pan(cx-v.lastX, cy-v.lastY)
+		}
+		v.lastX, v.lastY = cx, cy
+	case inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft):
+		if v.dragging {
+			return 0, 0, false
+		}
+		if v.inMinimap(cx, cy) {
+			mx, my, mw, _ := v.minimapRect()
+			scale := float64(v.worldWidth) / mw
+			v.CenterOn((float64(cx)-mx)*scale, (float64(cy)-my)*scale)
+			return 0, 0, false
+		}
+		return cx, cy, true
+	}
+	return 0, 0, false
+}
+
+// drawMinimap draws the whole world small in the top left, with the part on screen outlined.
+func (v *Viewport) drawMinimap(screen, world *ebiten.Image) {
+	if !v.ZoomedIn() {
+		return
+	}
+	x, y, width, height := v.minimapRect()
+	ebitenutil.DrawRect(screen, x-1, y-1, width+2, height+2, color.RGBA{30, 30, 40, 230})
+	scale := width / float64(v.worldWidth)
+	op := &ebiten.DrawImageOptions{Filter: ebiten.FilterLinear}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(x, y)
+	screen.DrawImage(world, op)
+
+	// The view, clipped to the minimap
+	left := math.Max(x, x+v.X*scale)
+	top := math.Max(y, y+v.Y*scale)
+	right := math.Min(x+width, x+(v.X+float64(v.Width)/v.Zoom)*scale)
+	bottom := math.Min(y+height, y+(v.Y+float64(v.Height)/v.Zoom)*scale)
+	ebitenutil.DrawLine(screen, left, top, right, top, colornames.Yellow)
+	ebitenutil.DrawLine(screen, left, bottom, right, bottom, colornames.Yellow)
+	ebitenutil.DrawLine(screen, left, top, left, bottom, colornames.Yellow)
+	ebitenutil.DrawLine(screen, right, top, right, bottom, colornames.Yellow)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}